@@ -0,0 +1,7 @@
+data "omni_etcd_backups" "production" {
+  cluster = "production"
+}
+
+output "latest_snapshot" {
+  value = data.omni_etcd_backups.production.backups[0].snapshot
+}
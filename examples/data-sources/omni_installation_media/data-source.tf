@@ -14,8 +14,8 @@ data "omni_installation_media" "detailed" {
     "ip=1.2.3.4::1.2.3.1:255.255.255.0::ens18:off:1.2.3.53"
   ]
   extensions = [ # Optional: extensions
-    "docker",
-    "k8s"
+    "siderolabs/iscsi-tools",
+    "siderolabs/qemu-guest-agent"
   ]
   use_siderolink_grpc_tunnel = false # Optional: Use SideroLink GRPC tunnel
 }
@@ -30,3 +30,48 @@ output "iso_pxe" {
 }
 
 
+
+output "iso_url" {
+  value = data.omni_installation_media.basic.iso_url
+}
+
+output "installer_image" {
+  value = data.omni_installation_media.basic.installer_image
+}
+
+# Select a single board computer image exactly
+data "omni_installation_media" "rpi" {
+  profile       = "metal"
+  overlay       = "rpi_generic"
+  arch          = "arm64"
+  talos_version = "v1.9.5"
+}
+
+# Bake a static network configuration into the image
+data "omni_installation_media" "edge" {
+  media_id      = "metal-amd64.raw.xz"
+  talos_version = "v1.9.5"
+  network_config = yamlencode({
+    addresses = [{
+      address  = "10.0.0.10/24"
+      linkName = "eth0"
+      family   = "inet4"
+      scope    = "global"
+      flags    = "permanent"
+      layer    = "platform"
+    }]
+    routes = [{
+      gateway     = "10.0.0.1"
+      outLinkName = "eth0"
+      table       = "main"
+      scope       = "global"
+      type        = "unicast"
+      family      = "inet4"
+      protocol    = "static"
+      layer       = "platform"
+    }]
+  })
+  meta_values = {
+    "13" = "edge"
+  }
+}
@@ -0,0 +1,6 @@
+data "omni_installation_medias" "all" {}
+
+output "arm64_media_ids" {
+  description = "IDs of all arm64 installation media"
+  value       = [for media in data.omni_installation_medias.all.medias : media.id if media.architecture == "arm64"]
+}
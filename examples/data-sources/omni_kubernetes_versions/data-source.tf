@@ -0,0 +1,11 @@
+data "omni_talos_versions" "all" {}
+
+# Newest Kubernetes 1.32 release supported by the newest Talos version
+data "omni_kubernetes_versions" "compatible" {
+  talos_version = data.omni_talos_versions.all.latest
+  constraint    = "~> 1.32.0"
+}
+
+output "kubernetes_version" {
+  value = data.omni_kubernetes_versions.compatible.latest
+}
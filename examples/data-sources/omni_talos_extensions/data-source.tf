@@ -0,0 +1,8 @@
+data "omni_talos_extensions" "v195" {
+  talos_version = "v1.9.5"
+}
+
+output "nvidia_extensions" {
+  description = "Names of all NVIDIA extensions"
+  value       = [for extension in data.omni_talos_extensions.v195.extensions : extension.name if strcontains(extension.name, "nvidia")]
+}
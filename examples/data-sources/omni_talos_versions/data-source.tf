@@ -0,0 +1,8 @@
+data "omni_talos_versions" "v19" {
+  constraint = "~> 1.9.0"
+}
+
+data "omni_installation_media" "iso" {
+  image_name    = "iso"
+  talos_version = data.omni_talos_versions.v19.latest
+}
@@ -0,0 +1,5 @@
+# Issue a short-lived key for an existing service account without storing it in the state
+ephemeral "omni_service_account_key" "ci" {
+  name = omni_service_account.ci.name
+  ttl  = "30m"
+}
@@ -0,0 +1,13 @@
+locals {
+  resources = provider::omni::decode_resources(file("${path.module}/resources.yaml"))
+
+  # the IDs of the machine classes of the document
+  machine_classes = [
+    for r in local.resources : r.metadata.id
+    if r.metadata.type == "MachineClasses.omni.sidero.dev"
+  ]
+}
+
+output "machine_classes" {
+  value = local.machine_classes
+}
@@ -0,0 +1,21 @@
+resource "omni_apply_yaml" "sysctls" {
+  yaml = provider::omni::encode_resource({
+    metadata = {
+      namespace = "default"
+      type      = "ConfigPatches.omni.sidero.dev"
+      id        = "500-talos-default-sysctls"
+      labels = {
+        "omni.sidero.dev/cluster" = "talos-default"
+      }
+    }
+    spec = {
+      data = yamlencode({
+        machine = {
+          sysctls = {
+            "vm.max_map_count" = "262144"
+          }
+        }
+      })
+    }
+  })
+}
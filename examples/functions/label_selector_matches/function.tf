@@ -0,0 +1,18 @@
+data "omni_machines" "all" {}
+
+locals {
+  workers_selector = "omni.sidero.dev/arch = amd64, !omni.sidero.dev/cluster"
+}
+
+# the machines the machine class can pick
+output "worker_candidates" {
+  value = [
+    for machine in data.omni_machines.all.machines : machine.id
+    if provider::omni::label_selector_matches(local.workers_selector, machine.labels)
+  ]
+}
+
+resource "omni_machine_class" "workers" {
+  name         = "workers"
+  match_labels = [local.workers_selector]
+}
@@ -0,0 +1,10 @@
+locals {
+  terms = provider::omni::parse_label_selector("omni.sidero.dev/arch = amd64, omni.sidero.dev/cores >= 4")
+
+  # the label keys the selector depends on
+  selector_keys = [for term in local.terms : term.key]
+}
+
+output "selector_keys" {
+  value = local.selector_keys
+}
@@ -0,0 +1,8 @@
+resource "omni_apply_yaml" "patches" {
+  yaml              = file("${path.module}/patches.yaml")
+  strict_versioning = true
+}
+
+output "sysctls_patch_version" {
+  value = omni_apply_yaml.patches.versions[provider::omni::resource_id("ConfigPatches.omni.sidero.dev", "default", "500-talos-default-sysctls")]
+}
@@ -0,0 +1,18 @@
+locals {
+  # computed without calling Omni, e.g. to build the image factory urls during the plan
+  longhorn_schematic_id = provider::omni::schematic_id(
+    ["siderolabs/iscsi-tools", "siderolabs/util-linux-tools"],
+    [],
+    {},
+    null,
+  )
+
+  rpi_schematic_id = provider::omni::schematic_id([], [], {}, {
+    image = "siderolabs/sbc-raspberrypi"
+    name  = "rpi_generic"
+  })
+}
+
+output "longhorn_installer" {
+  value = "factory.talos.dev/installer/${local.longhorn_schematic_id}:v1.9.5"
+}
@@ -0,0 +1,19 @@
+resource "omni_apply_yaml" "workers_install" {
+  yaml = provider::omni::encode_resource({
+    metadata = {
+      namespace = "default"
+      type      = "ConfigPatches.omni.sidero.dev"
+      id        = "400-talos-default-workers-install"
+      labels = {
+        "omni.sidero.dev/cluster"             = "talos-default"
+        "omni.sidero.dev/cluster-machine-set" = "talos-default-workers"
+      }
+    }
+    spec = {
+      data = provider::omni::talos_install_patch(
+        "/dev/nvme0n1",
+        "factory.talos.dev/installer/${provider::omni::schematic_id(["siderolabs/iscsi-tools"], [], {}, null)}:v1.9.5",
+      )
+    }
+  })
+}
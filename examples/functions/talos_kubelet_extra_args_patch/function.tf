@@ -0,0 +1,6 @@
+locals {
+  kubelet_patch = provider::omni::talos_kubelet_extra_args_patch({
+    "max-pods"                   = "250"
+    "rotate-server-certificates" = "true"
+  })
+}
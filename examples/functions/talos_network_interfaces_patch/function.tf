@@ -0,0 +1,23 @@
+locals {
+  # the shared VIP of the control plane nodes
+  controlplane_network_patch = provider::omni::talos_network_interfaces_patch([
+    {
+      interface = "eth0"
+      dhcp      = true
+      vip = {
+        ip = "10.0.0.10"
+      }
+    },
+    {
+      interface = "eth1"
+      addresses = ["192.168.10.2/24"]
+      routes = [
+        {
+          network = "192.168.20.0/24"
+          gateway = "192.168.10.1"
+        },
+      ]
+      mtu = 9000
+    },
+  ])
+}
@@ -0,0 +1,10 @@
+locals {
+  storage_node_patch = provider::omni::talos_node_labels_patch(
+    {
+      "node.kubernetes.io/role" = "storage"
+    },
+    {
+      "dedicated" = "storage:NoSchedule"
+    },
+  )
+}
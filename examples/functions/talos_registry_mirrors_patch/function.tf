@@ -0,0 +1,11 @@
+locals {
+  registry_mirrors_patch = provider::omni::talos_registry_mirrors_patch({
+    "docker.io" = {
+      endpoints    = ["https://mirror.example.com"]
+      overridePath = true
+    }
+    "ghcr.io" = {
+      endpoints = ["http://10.0.0.5:5000"]
+    }
+  })
+}
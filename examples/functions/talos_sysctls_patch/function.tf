@@ -0,0 +1,6 @@
+locals {
+  sysctls_patch = provider::omni::talos_sysctls_patch({
+    "net.ipv4.ip_forward" = "1"
+    "vm.max_map_count"    = "262144"
+  })
+}
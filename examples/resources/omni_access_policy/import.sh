@@ -0,0 +1,2 @@
+# The access policy is a singleton and is imported by its fixed ID
+terraform import omni_access_policy.acl access-policy
@@ -0,0 +1,42 @@
+resource "omni_access_policy" "acl" {
+  user_groups = {
+    platform = {
+      users = [
+        { match = "*@platform.example.com" },
+        { label_selectors = ["saml.omni.sidero.dev/groups=platform"] },
+      ]
+    }
+  }
+
+  cluster_groups = {
+    production = {
+      clusters = [{ match = "prod-*" }]
+    }
+  }
+
+  rules = [
+    {
+      users                         = ["group/platform"]
+      clusters                      = ["group/production"]
+      role                          = "Operator"
+      kubernetes_impersonate_groups = ["system:masters"]
+    },
+    {
+      users    = ["auditor@example.com"]
+      clusters = ["*"]
+      role     = "Reader"
+    },
+  ]
+
+  tests = [
+    {
+      name    = "platform operates production"
+      user    = { name = "jane.doe@platform.example.com" }
+      cluster = { name = "prod-eu-1" }
+      expected = {
+        role                          = "Operator"
+        kubernetes_impersonate_groups = ["system:masters"]
+      }
+    },
+  ]
+}
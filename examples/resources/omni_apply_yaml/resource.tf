@@ -1,13 +1,17 @@
+# Machine classes are managed with omni_machine_class, omni_apply_yaml applies the resources without a dedicated resource
 resource "omni_apply_yaml" "raw_yaml" {
   yaml = <<-EOT
 metadata:
     namespace: default
-    type: MachineClasses.omni.sidero.dev
-    id: aws
+    type: ConfigPatches.omni.sidero.dev
+    id: 500-talos-default-sysctls
+    labels:
+        omni.sidero.dev/cluster: talos-default
 spec:
-    matchlabels:
-        - omni.sidero.dev/platform = sth
-    autoprovision: null
+    data: |
+        machine:
+            sysctls:
+                vm.max_map_count: "262144"
 EOT
 }
 
@@ -15,18 +19,46 @@ resource "omni_apply_yaml" "encode_yaml" {
   yaml = yamlencode({
     metadata = {
       namespace = "default"
-      type      = "MachineClasses.omni.sidero.dev"
-      id        = "aws11"
+      type      = "ConfigPatches.omni.sidero.dev"
+      id        = "500-talos-default-workers-kubelet"
+      labels = {
+        "omni.sidero.dev/cluster"             = "talos-default"
+        "omni.sidero.dev/cluster-machine-set" = "talos-default-workers"
+      }
     }
     spec = {
-      matchlabels = [
-        "omni.sidero.dev/platform = sth"
-      ]
-      autoprovision = null
+      data = yamlencode({
+        machine = {
+          kubelet = {
+            extraArgs = {
+              max-pods = "250"
+            }
+          }
+        }
+      })
     }
   })
 }
 
 resource "omni_apply_yaml" "apply_from_yaml_file" {
   yaml = file("/path/to/file.yaml")
-}
\ No newline at end of file
+}
+
+# Fail the next update instead of overwriting the changes made outside of Terraform
+resource "omni_apply_yaml" "strict_versioning" {
+  strict_versioning = true
+
+  yaml = <<-EOT
+metadata:
+    namespace: default
+    type: ConfigPatches.omni.sidero.dev
+    id: 500-talos-default-install
+    labels:
+        omni.sidero.dev/cluster: talos-default
+spec:
+    data: |
+        machine:
+            install:
+                disk: /dev/nvme0n1
+EOT
+}
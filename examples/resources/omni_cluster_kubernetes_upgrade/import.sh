@@ -0,0 +1,2 @@
+# Kubernetes upgrades are imported by cluster name
+terraform import omni_cluster_kubernetes_upgrade.production production
@@ -0,0 +1,15 @@
+data "omni_talos_versions" "current" {
+  constraint = "~> 1.9.0"
+}
+
+data "omni_kubernetes_versions" "compatible" {
+  talos_version = data.omni_talos_versions.current.latest
+  constraint    = "~> 1.32.0"
+}
+
+# Keep the cluster on the newest compatible Kubernetes 1.32 release
+resource "omni_cluster_kubernetes_upgrade" "production" {
+  cluster            = "production"
+  kubernetes_version = data.omni_kubernetes_versions.compatible.latest
+  timeout            = "1h"
+}
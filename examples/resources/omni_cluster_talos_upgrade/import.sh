@@ -0,0 +1,2 @@
+# Talos upgrades are imported by cluster name
+terraform import omni_cluster_talos_upgrade.production production
@@ -0,0 +1,15 @@
+data "omni_talos_versions" "v19" {
+  constraint = "~> 1.9.0"
+}
+
+# Upgrade Talos and install the extensions in a single rolling upgrade
+resource "omni_cluster_talos_upgrade" "production" {
+  cluster       = "production"
+  talos_version = data.omni_talos_versions.v19.latest
+  extensions = [
+    "siderolabs/iscsi-tools",
+    "siderolabs/qemu-guest-agent",
+  ]
+  timeout           = "2h"
+  revert_on_failure = true
+}
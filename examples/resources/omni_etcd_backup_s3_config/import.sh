@@ -0,0 +1,2 @@
+# The S3 configuration is a singleton with a fixed ID
+terraform import omni_etcd_backup_s3_config.backups etcd-backup-s3-conf
@@ -0,0 +1,12 @@
+# Back up etcd before every Kubernetes upgrade
+resource "omni_etcd_manual_backup" "before_upgrade" {
+  cluster = "production"
+  triggers = {
+    kubernetes_version = "1.32.3"
+  }
+}
+
+resource "omni_cluster_kubernetes_upgrade" "production" {
+  cluster            = omni_etcd_manual_backup.before_upgrade.cluster
+  kubernetes_version = "1.32.3"
+}
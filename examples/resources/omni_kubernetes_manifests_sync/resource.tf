@@ -0,0 +1,12 @@
+resource "omni_cluster_kubernetes_upgrade" "production" {
+  cluster            = "production"
+  kubernetes_version = "1.32.3"
+}
+
+# Sync the bootstrap manifests every time Kubernetes is upgraded
+resource "omni_kubernetes_manifests_sync" "production" {
+  cluster = omni_cluster_kubernetes_upgrade.production.cluster
+  triggers = {
+    kubernetes_version = omni_cluster_kubernetes_upgrade.production.kubernetes_version
+  }
+}
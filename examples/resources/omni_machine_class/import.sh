@@ -0,0 +1,2 @@
+# Machine classes are imported by name
+terraform import omni_machine_class.bare_metal bare-metal
@@ -0,0 +1,30 @@
+# Select machines by labels
+resource "omni_machine_class" "bare_metal" {
+  name = "bare-metal"
+  match_labels = [
+    "omni.sidero.dev/arch = amd64, rack in (r1, r2)",
+    "zone = eu-1a",
+  ]
+}
+
+# Provision machines automatically through an infra provider
+resource "omni_machine_class" "aws" {
+  name = "aws"
+  auto_provision = {
+    provider_id = "aws"
+    kernel_args = ["console=ttyS0"]
+    meta_values = {
+      "13" = "custom-value"
+    }
+    grpc_tunnel = false
+    provider_data = yamlencode({
+      instance_type = "t3.large"
+    })
+  }
+}
+
+# Select the machines of a platform
+resource "omni_machine_class" "gcp" {
+  name         = "gcp"
+  match_labels = ["omni.sidero.dev/platform = gcp"]
+}
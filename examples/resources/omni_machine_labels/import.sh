@@ -0,0 +1,2 @@
+# Machine labels are imported by machine ID. All user labels of the machine become managed.
+terraform import omni_machine_labels.rack 6c9a1e2a-2f34-4d7e-9e0b-1a2b3c4d5e6f
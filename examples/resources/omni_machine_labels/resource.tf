@@ -0,0 +1,12 @@
+data "omni_machines" "all" {}
+
+# Label every machine by rack and zone, e.g. from a CMDB lookup
+resource "omni_machine_labels" "rack" {
+  for_each = { for machine in data.omni_machines.all.machines : machine.id => machine }
+
+  machine_id = each.key
+  labels = {
+    rack = "r1"
+    zone = "eu-1a"
+  }
+}
@@ -0,0 +1,2 @@
+# Machine sets are imported by ID, e.g. <cluster>-control-planes or <cluster>-workers
+terraform import omni_machine_set.workers production-workers
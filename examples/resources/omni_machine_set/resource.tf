@@ -0,0 +1,27 @@
+resource "omni_machine_set" "workers" {
+  cluster = "production"
+  role    = "workers"
+  machine_class = {
+    name          = "workers"
+    machine_count = 3
+  }
+}
+
+# Restore a cluster from an etcd backup of the original cluster
+data "omni_etcd_backups" "production" {
+  cluster = "production"
+}
+
+resource "omni_machine_set" "restored_control_planes" {
+  cluster = "production-restored"
+  role    = "control-plane"
+  machine_class = {
+    name          = "control-planes"
+    machine_count = 3
+  }
+  bootstrap_spec = {
+    source_cluster = "production"
+    cluster_uuid   = "4f7a1d3e-2c1b-4b8e-9f0a-8d6c5e4b3a21"
+    snapshot       = data.omni_etcd_backups.production.backups[0].snapshot
+  }
+}
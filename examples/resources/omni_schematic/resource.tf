@@ -0,0 +1,13 @@
+# Schematic created once and reused until one of its inputs changes
+resource "omni_schematic" "qemu" {
+  talos_version     = "1.9.0"
+  extensions        = ["siderolabs/qemu-guest-agent"]
+  extra_kernel_args = ["console=ttyS0"]
+  meta_values = {
+    "13" = "custom-value"
+  }
+}
+
+output "schematic_id" {
+  value = omni_schematic.qemu.schematic_id
+}
@@ -0,0 +1,2 @@
+# Service accounts are imported by name. A new key is issued on the next apply.
+terraform import omni_service_account.ci ci
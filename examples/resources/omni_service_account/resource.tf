@@ -0,0 +1,12 @@
+# Service account for CI, with a key renewed every 30 days
+resource "omni_service_account" "ci" {
+  name          = "ci"
+  role          = "Operator"
+  ttl           = "2160h"
+  rotation_days = 30
+}
+
+output "ci_service_account_key" {
+  value     = omni_service_account.ci.key
+  sensitive = true
+}
@@ -0,0 +1,2 @@
+# Users are imported by email
+terraform import omni_user.engineer jane.doe@example.com
@@ -0,0 +1,9 @@
+resource "omni_user" "engineer" {
+  email = "jane.doe@example.com"
+  role  = "Operator"
+
+  # Optional: SAML labels matched by access policies
+  saml_labels = {
+    groups = "platform"
+  }
+}
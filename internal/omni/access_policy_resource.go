@@ -0,0 +1,701 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/cosi/labels"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/auth"
+)
+
+const (
+	errValidationFailed = "Validation Error"
+
+	// accessPolicyGroupPrefix marks a reference to a user or cluster group in the rules
+	accessPolicyGroupPrefix = "group/"
+)
+
+var (
+	_ resource.Resource                   = &accessPolicyResource{}
+	_ resource.ResourceWithValidateConfig = &accessPolicyResource{}
+	_ resource.ResourceWithImportState    = &accessPolicyResource{}
+)
+
+func NewAccessPolicyResource() resource.Resource {
+	return &accessPolicyResource{}
+}
+
+type accessPolicyResource struct {
+	provider *omniProvider
+}
+
+type accessPolicyResourceModel struct {
+	ID            types.String                             `tfsdk:"id"`
+	UserGroups    map[string]accessPolicyUserGroupModel    `tfsdk:"user_groups"`
+	ClusterGroups map[string]accessPolicyClusterGroupModel `tfsdk:"cluster_groups"`
+	Rules         []accessPolicyRuleModel                  `tfsdk:"rules"`
+	Tests         []accessPolicyTestModel                  `tfsdk:"tests"`
+}
+
+type accessPolicyUserGroupModel struct {
+	Users []accessPolicyUserModel `tfsdk:"users"`
+}
+
+type accessPolicyUserModel struct {
+	Name           types.String   `tfsdk:"name"`
+	Match          types.String   `tfsdk:"match"`
+	LabelSelectors []types.String `tfsdk:"label_selectors"`
+}
+
+type accessPolicyClusterGroupModel struct {
+	Clusters []accessPolicyClusterModel `tfsdk:"clusters"`
+}
+
+type accessPolicyClusterModel struct {
+	Name  types.String `tfsdk:"name"`
+	Match types.String `tfsdk:"match"`
+}
+
+type accessPolicyRuleModel struct {
+	Users                       []types.String `tfsdk:"users"`
+	Clusters                    []types.String `tfsdk:"clusters"`
+	Role                        types.String   `tfsdk:"role"`
+	KubernetesImpersonateGroups []types.String `tfsdk:"kubernetes_impersonate_groups"`
+}
+
+type accessPolicyTestModel struct {
+	Name     types.String                   `tfsdk:"name"`
+	User     accessPolicyTestUserModel      `tfsdk:"user"`
+	Cluster  accessPolicyTestClusterModel   `tfsdk:"cluster"`
+	Expected *accessPolicyTestExpectedModel `tfsdk:"expected"`
+}
+
+type accessPolicyTestUserModel struct {
+	Name   types.String      `tfsdk:"name"`
+	Labels map[string]string `tfsdk:"labels"`
+}
+
+type accessPolicyTestClusterModel struct {
+	Name types.String `tfsdk:"name"`
+}
+
+type accessPolicyTestExpectedModel struct {
+	Role                        types.String   `tfsdk:"role"`
+	KubernetesImpersonateGroups []types.String `tfsdk:"kubernetes_impersonate_groups"`
+}
+
+func (r *accessPolicyResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_access_policy"
+}
+
+func (r *accessPolicyResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	impersonateGroups := schema.ListAttribute{
+		MarkdownDescription: "Kubernetes groups to impersonate",
+		Optional:            true,
+		ElementType:         types.StringType,
+		Validators: []validator.List{
+			listvalidator.SizeAtLeast(1),
+		},
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages the Omni access policy (the `" + auth.AccessPolicyID + "` resource). " +
+			"There is a single access policy in Omni, so only one instance of this resource should exist. " +
+			"Omni runs the `tests` when the policy is applied and rejects it if any of them fail.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the access policy",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"user_groups": schema.MapNestedAttribute{
+				MarkdownDescription: "User groups by name, referenced in rules as `group/<name>`",
+				Optional:            true,
+				Validators: []validator.Map{
+					mapvalidator.SizeAtLeast(1),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"users": schema.ListNestedAttribute{
+							MarkdownDescription: "Users of the group. Exactly one of `name`, `match` or `label_selectors` must be set",
+							Required:            true,
+							Validators: []validator.List{
+								listvalidator.SizeAtLeast(1),
+							},
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"name": schema.StringAttribute{
+										MarkdownDescription: "The exact identity of the user",
+										Optional:            true,
+									},
+									"match": schema.StringAttribute{
+										MarkdownDescription: "A glob pattern matching the identity of the user",
+										Optional:            true,
+									},
+									"label_selectors": schema.ListAttribute{
+										MarkdownDescription: "Label selectors matching the labels of the identity",
+										Optional:            true,
+										ElementType:         types.StringType,
+										Validators: []validator.List{
+											listvalidator.SizeAtLeast(1),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"cluster_groups": schema.MapNestedAttribute{
+				MarkdownDescription: "Cluster groups by name, referenced in rules as `group/<name>`",
+				Optional:            true,
+				Validators: []validator.Map{
+					mapvalidator.SizeAtLeast(1),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"clusters": schema.ListNestedAttribute{
+							MarkdownDescription: "Clusters of the group. Exactly one of `name` or `match` must be set",
+							Required:            true,
+							Validators: []validator.List{
+								listvalidator.SizeAtLeast(1),
+							},
+							NestedObject: schema.NestedAttributeObject{
+								Attributes: map[string]schema.Attribute{
+									"name": schema.StringAttribute{
+										MarkdownDescription: "The exact name of the cluster",
+										Optional:            true,
+									},
+									"match": schema.StringAttribute{
+										MarkdownDescription: "A glob pattern matching the name of the cluster",
+										Optional:            true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"rules": schema.ListNestedAttribute{
+				MarkdownDescription: "Rules granting users access to clusters",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"users": schema.ListAttribute{
+							MarkdownDescription: "Users or `group/<name>` user groups the rule applies to",
+							Required:            true,
+							ElementType:         types.StringType,
+							Validators: []validator.List{
+								listvalidator.SizeAtLeast(1),
+							},
+						},
+						"clusters": schema.ListAttribute{
+							MarkdownDescription: "Clusters or `group/<name>` cluster groups the rule applies to",
+							Required:            true,
+							ElementType:         types.StringType,
+							Validators: []validator.List{
+								listvalidator.SizeAtLeast(1),
+							},
+						},
+						"role": schema.StringAttribute{
+							MarkdownDescription: "The role granted on the clusters ('None', 'Reader', 'Operator' or 'Admin')",
+							Optional:            true,
+						},
+						"kubernetes_impersonate_groups": impersonateGroups,
+					},
+				},
+			},
+			"tests": schema.ListNestedAttribute{
+				MarkdownDescription: "Tests Omni runs against the policy before accepting it",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							MarkdownDescription: "The name of the test",
+							Required:            true,
+						},
+						"user": schema.SingleNestedAttribute{
+							MarkdownDescription: "The user to test with",
+							Required:            true,
+							Attributes: map[string]schema.Attribute{
+								"name": schema.StringAttribute{
+									MarkdownDescription: "The identity of the user",
+									Required:            true,
+								},
+								"labels": schema.MapAttribute{
+									MarkdownDescription: "The labels of the identity",
+									Optional:            true,
+									ElementType:         types.StringType,
+									Validators: []validator.Map{
+										mapvalidator.SizeAtLeast(1),
+									},
+								},
+							},
+						},
+						"cluster": schema.SingleNestedAttribute{
+							MarkdownDescription: "The cluster to test with",
+							Required:            true,
+							Attributes: map[string]schema.Attribute{
+								"name": schema.StringAttribute{
+									MarkdownDescription: "The name of the cluster",
+									Required:            true,
+								},
+							},
+						},
+						"expected": schema.SingleNestedAttribute{
+							MarkdownDescription: "The expected access of the user to the cluster",
+							Optional:            true,
+							Attributes: map[string]schema.Attribute{
+								"role": schema.StringAttribute{
+									MarkdownDescription: "The expected role",
+									Optional:            true,
+								},
+								"kubernetes_impersonate_groups": impersonateGroups,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *accessPolicyResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *accessPolicyResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	// Values are validated again on apply once they are known
+	if !req.Config.Raw.IsFullyKnown() {
+		return
+	}
+
+	var config accessPolicyResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := validateAccessPolicy(config.toSpec()); err != nil {
+		resp.Diagnostics.AddError(errValidationFailed, fmt.Sprintf("Invalid access policy: %v", err))
+	}
+}
+
+func (r *accessPolicyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan accessPolicyResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(ctx, plan); err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	plan.ID = types.StringValue(auth.AccessPolicyID)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *accessPolicyResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	st := r.provider.state
+
+	policy, err := safe.StateGetByID[*auth.AccessPolicy](ctx, st, auth.AccessPolicyID)
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read access policy: %v", err))
+		return
+	}
+
+	tfState := accessPolicyModelFromSpec(policy.TypedSpec().Value)
+	resp.Diagnostics.Append(resp.State.Set(ctx, tfState)...)
+}
+
+func (r *accessPolicyResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan accessPolicyResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(ctx, plan); err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, err.Error())
+		return
+	}
+
+	plan.ID = types.StringValue(auth.AccessPolicyID)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *accessPolicyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	st := r.provider.state
+
+	if err := st.Destroy(ctx, auth.NewAccessPolicy().Metadata()); err != nil && !state.IsNotFoundError(err) {
+		resp.Diagnostics.AddError(errDeleteFailed, fmt.Sprintf("Failed to delete access policy: %v", err))
+	}
+}
+
+func (r *accessPolicyResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if req.ID != auth.AccessPolicyID {
+		resp.Diagnostics.AddError("Import Error", fmt.Sprintf("The access policy can only be imported with the ID '%s'", auth.AccessPolicyID))
+		return
+	}
+
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// apply validates the planned access policy and creates or updates it in Omni.
+func (r *accessPolicyResource) apply(ctx context.Context, plan accessPolicyResourceModel) error {
+	st := r.provider.state
+
+	spec := plan.toSpec()
+	if err := validateAccessPolicy(spec); err != nil {
+		return fmt.Errorf("invalid access policy: %v", err)
+	}
+
+	policy := auth.NewAccessPolicy()
+	policy.TypedSpec().Value = spec
+
+	_, err := st.Get(ctx, policy.Metadata())
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return fmt.Errorf("failed to check access policy: %v", err)
+		}
+
+		if err := st.Create(ctx, policy); err != nil {
+			return fmt.Errorf("failed to create access policy: %v", err)
+		}
+
+		return nil
+	}
+
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, policy.Metadata(), func(existing *auth.AccessPolicy) error {
+		existing.TypedSpec().Value = spec
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to update access policy: %v", err)
+	}
+
+	return nil
+}
+
+func (m accessPolicyResourceModel) toSpec() *specs.AccessPolicySpec {
+	spec := &specs.AccessPolicySpec{}
+
+	if len(m.UserGroups) > 0 {
+		spec.UserGroups = map[string]*specs.AccessPolicyUserGroup{}
+	}
+
+	for name, group := range m.UserGroups {
+		userGroup := &specs.AccessPolicyUserGroup{}
+		for _, user := range group.Users {
+			userGroup.Users = append(userGroup.Users, &specs.AccessPolicyUserGroup_User{
+				Name:           user.Name.ValueString(),
+				Match:          user.Match.ValueString(),
+				LabelSelectors: stringValues(user.LabelSelectors),
+			})
+		}
+		spec.UserGroups[name] = userGroup
+	}
+
+	if len(m.ClusterGroups) > 0 {
+		spec.ClusterGroups = map[string]*specs.AccessPolicyClusterGroup{}
+	}
+
+	for name, group := range m.ClusterGroups {
+		clusterGroup := &specs.AccessPolicyClusterGroup{}
+		for _, cluster := range group.Clusters {
+			clusterGroup.Clusters = append(clusterGroup.Clusters, &specs.AccessPolicyClusterGroup_Cluster{
+				Name:  cluster.Name.ValueString(),
+				Match: cluster.Match.ValueString(),
+			})
+		}
+		spec.ClusterGroups[name] = clusterGroup
+	}
+
+	for _, rule := range m.Rules {
+		policyRule := &specs.AccessPolicyRule{
+			Users:    stringValues(rule.Users),
+			Clusters: stringValues(rule.Clusters),
+			Role:     rule.Role.ValueString(),
+		}
+
+		if len(rule.KubernetesImpersonateGroups) > 0 {
+			policyRule.Kubernetes = &specs.AccessPolicyRule_Kubernetes{
+				Impersonate: &specs.AccessPolicyRule_Kubernetes_Impersonate{
+					Groups: stringValues(rule.KubernetesImpersonateGroups),
+				},
+			}
+		}
+
+		spec.Rules = append(spec.Rules, policyRule)
+	}
+
+	for _, test := range m.Tests {
+		policyTest := &specs.AccessPolicyTest{
+			Name: test.Name.ValueString(),
+			User: &specs.AccessPolicyTest_User{
+				Name:   test.User.Name.ValueString(),
+				Labels: test.User.Labels,
+			},
+			Cluster: &specs.AccessPolicyTest_Cluster{
+				Name: test.Cluster.Name.ValueString(),
+			},
+			Expected: &specs.AccessPolicyTest_Expected{},
+		}
+
+		if test.Expected != nil {
+			policyTest.Expected.Role = test.Expected.Role.ValueString()
+
+			if len(test.Expected.KubernetesImpersonateGroups) > 0 {
+				policyTest.Expected.Kubernetes = &specs.AccessPolicyTest_Expected_Kubernetes{
+					Impersonate: &specs.AccessPolicyTest_Expected_Kubernetes_Impersonate{
+						Groups: stringValues(test.Expected.KubernetesImpersonateGroups),
+					},
+				}
+			}
+		}
+
+		spec.Tests = append(spec.Tests, policyTest)
+	}
+
+	return spec
+}
+
+func accessPolicyModelFromSpec(spec *specs.AccessPolicySpec) accessPolicyResourceModel {
+	model := accessPolicyResourceModel{
+		ID: types.StringValue(auth.AccessPolicyID),
+	}
+
+	if len(spec.GetUserGroups()) > 0 {
+		model.UserGroups = map[string]accessPolicyUserGroupModel{}
+	}
+
+	for name, group := range spec.GetUserGroups() {
+		var users []accessPolicyUserModel
+		for _, user := range group.GetUsers() {
+			users = append(users, accessPolicyUserModel{
+				Name:           optionalStringValue(user.GetName()),
+				Match:          optionalStringValue(user.GetMatch()),
+				LabelSelectors: stringList(user.GetLabelSelectors()),
+			})
+		}
+		model.UserGroups[name] = accessPolicyUserGroupModel{Users: users}
+	}
+
+	if len(spec.GetClusterGroups()) > 0 {
+		model.ClusterGroups = map[string]accessPolicyClusterGroupModel{}
+	}
+
+	for name, group := range spec.GetClusterGroups() {
+		var clusters []accessPolicyClusterModel
+		for _, cluster := range group.GetClusters() {
+			clusters = append(clusters, accessPolicyClusterModel{
+				Name:  optionalStringValue(cluster.GetName()),
+				Match: optionalStringValue(cluster.GetMatch()),
+			})
+		}
+		model.ClusterGroups[name] = accessPolicyClusterGroupModel{Clusters: clusters}
+	}
+
+	for _, rule := range spec.GetRules() {
+		model.Rules = append(model.Rules, accessPolicyRuleModel{
+			Users:                       stringList(rule.GetUsers()),
+			Clusters:                    stringList(rule.GetClusters()),
+			Role:                        optionalStringValue(rule.GetRole()),
+			KubernetesImpersonateGroups: stringList(rule.GetKubernetes().GetImpersonate().GetGroups()),
+		})
+	}
+
+	for _, test := range spec.GetTests() {
+		policyTest := accessPolicyTestModel{
+			Name: types.StringValue(test.GetName()),
+			User: accessPolicyTestUserModel{
+				Name: types.StringValue(test.GetUser().GetName()),
+			},
+			Cluster: accessPolicyTestClusterModel{
+				Name: types.StringValue(test.GetCluster().GetName()),
+			},
+		}
+
+		if len(test.GetUser().GetLabels()) > 0 {
+			policyTest.User.Labels = test.GetUser().GetLabels()
+		}
+
+		expected := test.GetExpected()
+		if expected.GetRole() != "" || len(expected.GetKubernetes().GetImpersonate().GetGroups()) > 0 {
+			policyTest.Expected = &accessPolicyTestExpectedModel{
+				Role:                        optionalStringValue(expected.GetRole()),
+				KubernetesImpersonateGroups: stringList(expected.GetKubernetes().GetImpersonate().GetGroups()),
+			}
+		}
+
+		model.Tests = append(model.Tests, policyTest)
+	}
+
+	return model
+}
+
+// validateAccessPolicy performs the checks Omni runs on the access policy that do not need any cluster or user data.
+func validateAccessPolicy(spec *specs.AccessPolicySpec) error {
+	var errs []error
+
+	validRoles := []string{"", roleNone, roleReader, roleOperator, roleAdmin}
+
+	for name, group := range spec.GetUserGroups() {
+		for i, user := range group.GetUsers() {
+			set := 0
+			for _, isSet := range []bool{user.GetName() != "", user.GetMatch() != "", len(user.GetLabelSelectors()) > 0} {
+				if isSet {
+					set++
+				}
+			}
+
+			if set != 1 {
+				errs = append(errs, fmt.Errorf("user group %q: user %d must have exactly one of name, match or label_selectors set", name, i))
+			}
+
+			if user.GetMatch() != "" {
+				if _, err := filepath.Match(user.GetMatch(), ""); err != nil {
+					errs = append(errs, fmt.Errorf("user group %q: user %d: invalid match pattern %q: %v", name, i, user.GetMatch(), err))
+				}
+			}
+
+			if _, err := labels.ParseSelectors(user.GetLabelSelectors()); err != nil {
+				errs = append(errs, fmt.Errorf("user group %q: user %d: invalid label selectors: %v", name, i, err))
+			}
+		}
+	}
+
+	for name, group := range spec.GetClusterGroups() {
+		for i, cluster := range group.GetClusters() {
+			if (cluster.GetName() == "") == (cluster.GetMatch() == "") {
+				errs = append(errs, fmt.Errorf("cluster group %q: cluster %d must have exactly one of name or match set", name, i))
+			}
+
+			if cluster.GetMatch() != "" {
+				if _, err := filepath.Match(cluster.GetMatch(), ""); err != nil {
+					errs = append(errs, fmt.Errorf("cluster group %q: cluster %d: invalid match pattern %q: %v", name, i, cluster.GetMatch(), err))
+				}
+			}
+		}
+	}
+
+	for i, rule := range spec.GetRules() {
+		if !slices.Contains(validRoles, rule.GetRole()) {
+			errs = append(errs, fmt.Errorf("rule %d: unknown role %q", i, rule.GetRole()))
+		}
+
+		for _, user := range rule.GetUsers() {
+			if group, ok := strings.CutPrefix(user, accessPolicyGroupPrefix); ok {
+				if _, exists := spec.GetUserGroups()[group]; !exists {
+					errs = append(errs, fmt.Errorf("rule %d: user group %q is not defined", i, group))
+				}
+			}
+		}
+
+		for _, cluster := range rule.GetClusters() {
+			if group, ok := strings.CutPrefix(cluster, accessPolicyGroupPrefix); ok {
+				if _, exists := spec.GetClusterGroups()[group]; !exists {
+					errs = append(errs, fmt.Errorf("rule %d: cluster group %q is not defined", i, group))
+				}
+			}
+		}
+	}
+
+	testNames := map[string]struct{}{}
+
+	for i, test := range spec.GetTests() {
+		if _, exists := testNames[test.GetName()]; exists {
+			errs = append(errs, fmt.Errorf("test %d: duplicate test name %q", i, test.GetName()))
+		}
+
+		testNames[test.GetName()] = struct{}{}
+
+		if !slices.Contains(validRoles, test.GetExpected().GetRole()) {
+			errs = append(errs, fmt.Errorf("test %q: unknown expected role %q", test.GetName(), test.GetExpected().GetRole()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Helper functions
+func stringValues(values []types.String) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, v.ValueString())
+	}
+
+	return result
+}
+
+func stringList(values []string) []types.String {
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := make([]types.String, 0, len(values))
+	for _, v := range values {
+		result = append(result, types.StringValue(v))
+	}
+
+	return result
+}
+
+func optionalStringValue(value string) types.String {
+	if value == "" {
+		return types.StringNull()
+	}
+
+	return types.StringValue(value)
+}
@@ -0,0 +1,160 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessPolicyResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_access_policy" "test" {
+  user_groups = {
+    platform = {
+      users = [{ match = "*@example.com" }]
+    }
+  }
+  cluster_groups = {
+    production = {
+      clusters = [{ match = "prod-*" }]
+    }
+  }
+  rules = [{
+    users                         = ["group/platform"]
+    clusters                      = ["group/production"]
+    role                          = "Operator"
+    kubernetes_impersonate_groups = ["system:masters"]
+  }]
+  tests = [{
+    name    = "platform can operate production"
+    user    = { name = "jane.doe@example.com" }
+    cluster = { name = "prod-eu-1" }
+    expected = {
+      role                          = "Operator"
+      kubernetes_impersonate_groups = ["system:masters"]
+    }
+  }]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_access_policy.test", "id", "access-policy"),
+					resource.TestCheckResourceAttr("omni_access_policy.test", "rules.0.role", "Operator"),
+				),
+			},
+			{
+				ResourceName:      "omni_access_policy.test",
+				ImportState:       true,
+				ImportStateId:     "access-policy",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestValidateAccessPolicy(t *testing.T) {
+	valid := &specs.AccessPolicySpec{
+		UserGroups: map[string]*specs.AccessPolicyUserGroup{
+			"platform": {Users: []*specs.AccessPolicyUserGroup_User{
+				{Match: "*@example.com"},
+				{LabelSelectors: []string{"saml.omni.sidero.dev/groups=platform"}},
+			}},
+		},
+		ClusterGroups: map[string]*specs.AccessPolicyClusterGroup{
+			"production": {Clusters: []*specs.AccessPolicyClusterGroup_Cluster{{Name: "prod"}}},
+		},
+		Rules: []*specs.AccessPolicyRule{
+			{Users: []string{"group/platform"}, Clusters: []string{"group/production"}, Role: "Operator"},
+		},
+	}
+	require.NoError(t, validateAccessPolicy(valid))
+
+	invalid := &specs.AccessPolicySpec{
+		UserGroups: map[string]*specs.AccessPolicyUserGroup{
+			"platform": {Users: []*specs.AccessPolicyUserGroup_User{{Name: "jane@example.com", Match: "*"}}},
+		},
+		ClusterGroups: map[string]*specs.AccessPolicyClusterGroup{
+			"production": {Clusters: []*specs.AccessPolicyClusterGroup_Cluster{{Match: "[prod"}}},
+		},
+		Rules: []*specs.AccessPolicyRule{
+			{Users: []string{"group/missing"}, Clusters: []string{"group/production"}, Role: "Owner"},
+		},
+	}
+
+	err := validateAccessPolicy(invalid)
+	require.Error(t, err)
+	require.ErrorContains(t, err, "exactly one of name, match or label_selectors")
+	require.ErrorContains(t, err, "invalid match pattern")
+	require.ErrorContains(t, err, `user group "missing" is not defined`)
+	require.ErrorContains(t, err, `unknown role "Owner"`)
+}
+
+func TestAccessPolicyModelRoundTrip(t *testing.T) {
+	model := accessPolicyResourceModel{
+		ID: types.StringValue("access-policy"),
+		UserGroups: map[string]accessPolicyUserGroupModel{
+			"platform": {Users: []accessPolicyUserModel{{Name: types.StringNull(), Match: types.StringValue("*@example.com")}}},
+		},
+		Rules: []accessPolicyRuleModel{{
+			Users:                       []types.String{types.StringValue("group/platform")},
+			Clusters:                    []types.String{types.StringValue("*")},
+			Role:                        types.StringValue("Reader"),
+			KubernetesImpersonateGroups: []types.String{types.StringValue("read-only")},
+		}},
+		Tests: []accessPolicyTestModel{{
+			Name:    types.StringValue("reader"),
+			User:    accessPolicyTestUserModel{Name: types.StringValue("jane@example.com")},
+			Cluster: accessPolicyTestClusterModel{Name: types.StringValue("dev")},
+			Expected: &accessPolicyTestExpectedModel{
+				Role: types.StringValue("Reader"),
+			},
+		}},
+	}
+
+	require.Equal(t, model, accessPolicyModelFromSpec(model.toSpec()))
+}
+
+func TestAccessPolicyRejectsEmptyCollections(t *testing.T) {
+	var resp fwresource.SchemaResponse
+
+	NewAccessPolicyResource().Schema(t.Context(), fwresource.SchemaRequest{}, &resp)
+	require.False(t, resp.Diagnostics.HasError())
+
+	rule := resp.Schema.Attributes["rules"].(schema.ListNestedAttribute).NestedObject.Attributes           //nolint:forcetypeassert
+	userGroup := resp.Schema.Attributes["user_groups"].(schema.MapNestedAttribute).NestedObject.Attributes //nolint:forcetypeassert
+
+	// Omni does not keep empty lists, so they would be read back as null
+	for name, validators := range map[string][]validator.List{
+		"rules":                               resp.Schema.Attributes["rules"].(schema.ListNestedAttribute).Validators, //nolint:forcetypeassert
+		"rules.users":                         rule["users"].(schema.ListAttribute).Validators,                         //nolint:forcetypeassert
+		"rules.clusters":                      rule["clusters"].(schema.ListAttribute).Validators,                      //nolint:forcetypeassert
+		"rules.kubernetes_impersonate_groups": rule["kubernetes_impersonate_groups"].(schema.ListAttribute).Validators, //nolint:forcetypeassert
+		"user_groups.users":                   userGroup["users"].(schema.ListNestedAttribute).Validators,              //nolint:forcetypeassert
+	} {
+		var listResp validator.ListResponse
+
+		for _, v := range validators {
+			v.ValidateList(t.Context(), validator.ListRequest{ConfigValue: types.ListValueMust(types.StringType, []attr.Value{})}, &listResp)
+		}
+
+		require.True(t, listResp.Diagnostics.HasError(), name)
+	}
+
+	var mapResp validator.MapResponse
+
+	for _, v := range resp.Schema.Attributes["cluster_groups"].(schema.MapNestedAttribute).Validators { //nolint:forcetypeassert
+		v.ValidateMap(t.Context(), validator.MapRequest{ConfigValue: types.MapValueMust(types.StringType, map[string]attr.Value{})}, &mapResp)
+	}
+
+	require.True(t, mapResp.Diagnostics.HasError())
+}
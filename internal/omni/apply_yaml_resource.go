@@ -23,6 +23,8 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
 	"gopkg.in/yaml.v3"
 )
 
@@ -35,6 +37,7 @@ const (
 	errStateError             = "State Error"
 	errContextError           = "Context Error"
 	errYAMLDecodingError      = "YAML Decoding Error"
+	errVersionConflict        = "Version Conflict"
 )
 
 var _ resource.Resource = &applyYamlResource{}
@@ -48,8 +51,10 @@ type applyYamlResource struct {
 }
 
 type applyYamlResourceModel struct {
-	ID   types.String `tfsdk:"id"`
-	Yaml types.String `tfsdk:"yaml"`
+	ID               types.String `tfsdk:"id"`
+	Yaml             types.String `tfsdk:"yaml"`
+	StrictVersioning types.Bool   `tfsdk:"strict_versioning"`
+	Versions         types.Map    `tfsdk:"versions"`
 }
 
 // ResourceOperationMode defines how the resource operation should behave
@@ -78,6 +83,17 @@ func (r *applyYamlResource) Schema(_ context.Context, _ resource.SchemaRequest,
 				Required:    true,
 				Description: "The YAML configuration to apply to Omni.",
 			},
+			"strict_versioning": schema.BoolAttribute{
+				Optional: true,
+				Description: "Records the version of every applied resource and fails the next update with a conflict, showing the remote change, " +
+					"if a resource was changed outside of Terraform since it was applied, instead of overwriting the change. " +
+					"A change made during the apply is not overwritten either. The labels and annotations prefixed with `omni.sidero.dev/` are set by Omni and are not compared.",
+			},
+			"versions": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The versions of the applied resources by `namespace/type/id`, only set with `strict_versioning`.",
+			},
 		},
 	}
 }
@@ -100,7 +116,7 @@ func (r *applyYamlResource) Configure(ctx context.Context, req resource.Configur
 }
 
 func (r *applyYamlResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	st := r.provider.client.Omni().State()
+	st := r.provider.state
 
 	var plan applyYamlResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
@@ -117,7 +133,7 @@ func (r *applyYamlResource) Create(ctx context.Context, req resource.CreateReque
 	var idArr []string
 
 	for _, resource := range resources {
-		if err := r.processResource(ctx, st, resource, ModeCreateOnly); err != nil {
+		if err := r.processResource(ctx, st, resource, nil, ModeCreateOnly); err != nil {
 			resp.Diagnostics.AddError(errCreationFailed, err.Error())
 			return
 		}
@@ -125,11 +141,13 @@ func (r *applyYamlResource) Create(ctx context.Context, req resource.CreateReque
 	}
 
 	plan.ID = types.StringValue(r.generateResourceId(idArr))
+	plan.Versions, diags = r.appliedVersions(ctx, st, resources, plan.StrictVersioning.ValueBool())
+	resp.Diagnostics.Append(diags...)
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
 func (r *applyYamlResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	st := r.provider.client.Omni().State()
+	st := r.provider.state
 
 	var tfState, plan applyYamlResourceModel
 
@@ -155,6 +173,22 @@ func (r *applyYamlResource) Update(ctx context.Context, req resource.UpdateReque
 		return
 	}
 
+	// Refuse to overwrite the changes made outside of Terraform since the last apply
+	var strictResources map[string]cosi_res.Resource
+
+	if plan.StrictVersioning.ValueBool() {
+		resp.Diagnostics.Append(r.checkVersions(ctx, st, stateResources, tfState.Versions)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		strictResources, diags = versionedResources(ctx, stateResources, tfState.Versions)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	var idArr []string
 
 	// Process plan resources and remove matched ones from stateResources
@@ -167,7 +201,7 @@ func (r *applyYamlResource) Update(ctx context.Context, req resource.UpdateReque
 			}
 		}
 
-		if err := r.processResource(ctx, st, resource, ModeCreateOrUpdate); err != nil {
+		if err := r.processResource(ctx, st, resource, strictResources[pointerKey(resource.Metadata())], ModeCreateOrUpdate); err != nil {
 			resp.Diagnostics.AddError(errUpdateFailed, err.Error())
 			return
 		}
@@ -183,6 +217,8 @@ func (r *applyYamlResource) Update(ctx context.Context, req resource.UpdateReque
 	}
 
 	plan.ID = types.StringValue(r.generateResourceId(idArr))
+	plan.Versions, diags = r.appliedVersions(ctx, st, planResources, plan.StrictVersioning.ValueBool())
+	resp.Diagnostics.Append(diags...)
 	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
 }
 
@@ -190,7 +226,7 @@ func (r *applyYamlResource) Read(ctx context.Context, req resource.ReadRequest,
 }
 
 func (r *applyYamlResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	st := r.provider.client.Omni().State()
+	st := r.provider.state
 
 	var plan applyYamlResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &plan)...)
@@ -212,7 +248,9 @@ func (r *applyYamlResource) Delete(ctx context.Context, req resource.DeleteReque
 	}
 }
 
-func (r *applyYamlResource) processResource(ctx context.Context, st state.State, resource cosi_res.Resource, mode ResourceOperationMode) error {
+// processResource creates or updates the resource. With applied set, the resource is only updated while it matches
+// the previously applied one, so the changes made outside of Terraform are not overwritten, even during the apply.
+func (r *applyYamlResource) processResource(ctx context.Context, st state.State, resource, applied cosi_res.Resource, mode ResourceOperationMode) error {
 	result, err := st.Get(ctx, resource.Metadata())
 	if err != nil {
 		if state.IsNotFoundError(err) {
@@ -231,9 +269,42 @@ func (r *applyYamlResource) processResource(ctx context.Context, st state.State,
 			return fmt.Errorf("resource '%s' of type '%s' already exists",
 				resource.Metadata().ID(), resource.Metadata().Type())
 		}
-		// ModeCreateOrUpdate: update existing resource
-		resource.Metadata().SetVersion(result.Metadata().Version())
-		if err := st.Update(ctx, resource); err != nil {
+		if err := checkUnchanged(applied, result); err != nil {
+			return err
+		}
+
+		// ModeCreateOrUpdate: update existing resource, reading the version again if it changed meanwhile
+		err := r.provider.retry.do(ctx, isRetryableUpdateError, func() error {
+			resource.Metadata().SetVersion(result.Metadata().Version())
+
+			err := st.Update(ctx, resource)
+			if isVersionConflictError(err) {
+				latest, getErr := st.Get(ctx, resource.Metadata())
+				if getErr != nil {
+					return getErr
+				}
+
+				// an update that timed out may still have landed, then there is nothing left to apply
+				diff, diffErr := remoteChange(resource, latest)
+				if diffErr != nil {
+					return diffErr
+				}
+
+				if diff == "" {
+					return nil
+				}
+
+				// the conflict is retried only if the resource still matches the applied one
+				if checkErr := checkUnchanged(applied, latest); checkErr != nil {
+					return checkErr
+				}
+
+				result = latest
+			}
+
+			return err
+		})
+		if err != nil {
 			return fmt.Errorf("failed to update resource '%s' of type '%s': %v",
 				resource.Metadata().ID(), resource.Metadata().Type(), err)
 		}
@@ -291,10 +362,234 @@ func (r *applyYamlResource) deleteResource(ctx context.Context, st state.State,
 			resource.Metadata().ID(), resource.Metadata().Type(), err)
 	}
 
-	if err := st.Destroy(ctx, resource.Metadata()); err != nil {
+	err = r.provider.retry.do(ctx, isTransientError, func() error {
+		err := st.Destroy(ctx, resource.Metadata())
+		if state.IsNotFoundError(err) {
+			return nil // Resource deleted by an earlier attempt
+		}
+
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete resource '%s' of type '%s': %v",
 			resource.Metadata().ID(), resource.Metadata().Type(), err)
 	}
 
 	return nil
 }
+
+// appliedVersions reads the versions of the applied resources, the Omni client does not return the version of a created resource
+// with every state implementation, so the resources are read again.
+func (r *applyYamlResource) appliedVersions(ctx context.Context, st state.State, resources []cosi_res.Resource, strict bool) (types.Map, diag.Diagnostics) {
+	if !strict {
+		return types.MapNull(types.StringType), nil
+	}
+
+	versions := make(map[string]string, len(resources))
+
+	for _, resource := range resources {
+		current, err := st.Get(ctx, resource.Metadata())
+		if err != nil {
+			var diags diag.Diagnostics
+
+			diags.AddError(errStateError, fmt.Sprintf("failed to read the version of resource '%s' of type '%s': %v",
+				resource.Metadata().ID(), resource.Metadata().Type(), err))
+
+			return types.MapNull(types.StringType), diags
+		}
+
+		versions[pointerKey(resource.Metadata())] = current.Metadata().Version().String()
+	}
+
+	return types.MapValueFrom(ctx, types.StringType, versions)
+}
+
+// checkVersions returns a conflict for every applied resource which was changed or deleted outside of Terraform.
+//
+// A version change alone is not a conflict, e.g. the controllers add finalizers to the resources.
+func (r *applyYamlResource) checkVersions(ctx context.Context, st state.State, applied []cosi_res.Resource, recorded types.Map) diag.Diagnostics {
+	var (
+		diags    diag.Diagnostics
+		versions map[string]string
+	)
+
+	if recorded.IsNull() || recorded.IsUnknown() {
+		return diags
+	}
+
+	diags.Append(recorded.ElementsAs(ctx, &versions, false)...)
+	if diags.HasError() {
+		return diags
+	}
+
+	for _, resource := range applied {
+		version, ok := versions[pointerKey(resource.Metadata())]
+		if !ok {
+			continue
+		}
+
+		current, err := st.Get(ctx, resource.Metadata())
+		if err != nil {
+			if state.IsNotFoundError(err) {
+				diags.AddError(errVersionConflict, fmt.Sprintf("Resource '%s' of type '%s' was applied at version %s and deleted outside of Terraform since.",
+					resource.Metadata().ID(), resource.Metadata().Type(), version))
+
+				continue
+			}
+
+			diags.AddError(errStateError, fmt.Sprintf("failed to check resource '%s' of type '%s': %v",
+				resource.Metadata().ID(), resource.Metadata().Type(), err))
+
+			continue
+		}
+
+		if current.Metadata().Version().String() == version {
+			continue
+		}
+
+		diff, err := remoteChange(resource, current)
+		if err != nil {
+			diags.AddError(errStateError, fmt.Sprintf("failed to compare resource '%s' of type '%s': %v",
+				resource.Metadata().ID(), resource.Metadata().Type(), err))
+
+			continue
+		}
+
+		if diff == "" {
+			continue
+		}
+
+		diags.AddError(errVersionConflict, fmt.Sprintf(
+			"Resource '%s' of type '%s' was applied at version %s and changed outside of Terraform to version %s:\n\n%s\n"+
+				"Update the yaml to keep the change or disable strict_versioning to overwrite it.",
+			resource.Metadata().ID(), resource.Metadata().Type(), version, current.Metadata().Version(), diff))
+	}
+
+	return diags
+}
+
+// versionedResources returns the applied resources with a recorded version by their pointer key.
+func versionedResources(ctx context.Context, applied []cosi_res.Resource, recorded types.Map) (map[string]cosi_res.Resource, diag.Diagnostics) {
+	var versions map[string]string
+
+	if recorded.IsNull() || recorded.IsUnknown() {
+		return nil, nil
+	}
+
+	diags := recorded.ElementsAs(ctx, &versions, false)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	result := make(map[string]cosi_res.Resource, len(versions))
+
+	for _, resource := range applied {
+		if _, ok := versions[pointerKey(resource.Metadata())]; ok {
+			result[pointerKey(resource.Metadata())] = resource
+		}
+	}
+
+	return result, diags
+}
+
+// checkUnchanged returns an error if the current resource was changed outside of Terraform since it was applied, a nil applied resource is never checked.
+func checkUnchanged(applied, current cosi_res.Resource) error {
+	if applied == nil {
+		return nil
+	}
+
+	diff, err := remoteChange(applied, current)
+	if err != nil {
+		return fmt.Errorf("failed to compare resource '%s' of type '%s': %v", current.Metadata().ID(), current.Metadata().Type(), err)
+	}
+
+	if diff != "" {
+		return fmt.Errorf("resource '%s' of type '%s' was changed outside of Terraform during the apply, it is not overwritten with strict_versioning:\n\n%s",
+			current.Metadata().ID(), current.Metadata().Type(), diff)
+	}
+
+	return nil
+}
+
+// remoteChange returns the diff of the labels, annotations and spec of the applied and the current resource.
+func remoteChange(applied, current cosi_res.Resource) (string, error) {
+	before, err := conflictView(applied)
+	if err != nil {
+		return "", err
+	}
+
+	after, err := conflictView(current)
+	if err != nil {
+		return "", err
+	}
+
+	if before == after {
+		return "", nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: "applied",
+		ToFile:   "remote",
+		Context:  3,
+	})
+}
+
+// conflictView encodes the user managed parts of the resource, the labels and annotations set by Omni are left out.
+//
+// The resource is decoded into its registered type first, so the applied and the current resource are encoded the same way.
+func conflictView(r cosi_res.Resource) (string, error) {
+	data, err := canonicalResourceYAML(r)
+	if err != nil {
+		return "", err
+	}
+
+	var typed protobuf.YAMLResource
+
+	if err = yaml.Unmarshal([]byte(data), &typed); err != nil {
+		return "", fmt.Errorf("failed to decode resource '%s' of type '%s': %w", r.Metadata().ID(), r.Metadata().Type(), err)
+	}
+
+	out, err := cosi_res.MarshalYAML(typed.Resource())
+	if err != nil {
+		return "", err
+	}
+
+	var node yaml.Node
+
+	if err = node.Encode(out); err != nil {
+		return "", fmt.Errorf("failed to encode resource '%s' of type '%s': %w", r.Metadata().ID(), r.Metadata().Type(), err)
+	}
+
+	view := struct {
+		Labels      map[string]string `yaml:"labels,omitempty"`
+		Annotations map[string]string `yaml:"annotations,omitempty"`
+		Spec        *yaml.Node        `yaml:"spec"`
+	}{
+		Labels:      withoutSystemKeys(r.Metadata().Labels().Raw()),
+		Annotations: withoutSystemKeys(r.Metadata().Annotations().Raw()),
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "spec" {
+			view.Spec = node.Content[i+1]
+		}
+	}
+
+	result, err := yaml.Marshal(view)
+
+	return string(result), err
+}
+
+func withoutSystemKeys(values map[string]string) map[string]string {
+	result := make(map[string]string, len(values))
+
+	for key, value := range values {
+		if !strings.HasPrefix(key, omni.SystemLabelPrefix) {
+			result[key] = value
+		}
+	}
+
+	return result
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/cosi-project/runtime/pkg/safe"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 )
@@ -15,7 +18,7 @@ func TestApplyYamlResource(t *testing.T) {
 	testApplyYamlId := "MachineClasses.omni.sidero.dev.test-apply-yaml"
 	testApplyYaml2Id := "MachineClasses.omni.sidero.dev.test-apply-yaml-2"
 	resource.Test(t, resource.TestCase{
-		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ProtoV6ProviderFactories: testAccOfflineProviderFactories(t),
 		Steps: []resource.TestStep{
 			{
 				// Create new resource
@@ -133,3 +136,95 @@ func TestGenerateResourceId(t *testing.T) {
 	require.Equal(t, id1, id2, "IDs with same order should be equal")
 	require.NotEqual(t, id1, id3, "IDs with different order should differ")
 }
+
+func TestApplyYamlCheckVersions(t *testing.T) {
+	r := &applyYamlResource{}
+	ctx := t.Context()
+	st := newTestState()
+
+	applied, diags := r.decodeYAMLResources(ctx, `metadata:
+    namespace: default
+    type: MachineClasses.omni.sidero.dev
+    id: workers
+spec:
+    matchlabels:
+        - omni.sidero.dev/arch = amd64
+`)
+	require.False(t, diags.HasError())
+	require.NoError(t, st.Create(ctx, applied[0]))
+
+	versions, diags := r.appliedVersions(ctx, st, applied, false)
+	require.False(t, diags.HasError())
+	require.True(t, versions.IsNull())
+
+	versions, diags = r.appliedVersions(ctx, st, applied, true)
+	require.False(t, diags.HasError())
+	require.Equal(t, `{"default/MachineClasses.omni.sidero.dev/workers":"1"}`, versions.String())
+
+	require.False(t, r.checkVersions(ctx, st, applied, versions).HasError())
+
+	// a new finalizer moves the version without changing the resource
+	require.NoError(t, st.AddFinalizer(ctx, applied[0].Metadata(), "MachineSetController"))
+	require.False(t, r.checkVersions(ctx, st, applied, versions).HasError())
+
+	// the labels set by Omni are not changes of the user
+	_, err := safe.StateUpdateWithConflicts(ctx, st, applied[0].Metadata(), func(r *omni.MachineClass) error {
+		r.Metadata().Labels().Set(omni.SystemLabelPrefix+"managed", "")
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.False(t, r.checkVersions(ctx, st, applied, versions).HasError())
+
+	_, err = safe.StateUpdateWithConflicts(ctx, st, applied[0].Metadata(), func(r *omni.MachineClass) error {
+		r.TypedSpec().Value.MatchLabels = []string{"omni.sidero.dev/arch = arm64"}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	diags = r.checkVersions(ctx, st, applied, versions)
+	require.True(t, diags.HasError())
+	require.Equal(t, errVersionConflict, diags[0].Summary())
+	require.Contains(t, diags[0].Detail(), "changed outside of Terraform to version 4")
+	require.Contains(t, diags[0].Detail(), "-        - omni.sidero.dev/arch = amd64")
+	require.Contains(t, diags[0].Detail(), "+        - omni.sidero.dev/arch = arm64")
+
+	require.NoError(t, st.RemoveFinalizer(ctx, applied[0].Metadata(), "MachineSetController"))
+	require.NoError(t, st.Destroy(ctx, applied[0].Metadata()))
+
+	diags = r.checkVersions(ctx, st, applied, versions)
+	require.True(t, diags.HasError())
+	require.Contains(t, diags[0].Detail(), "deleted outside of Terraform")
+}
+
+func TestApplyYamlRemoteChangeComparesTypedResources(t *testing.T) {
+	r := &applyYamlResource{}
+
+	applied, diags := r.decodeYAMLResources(t.Context(), `metadata:
+    namespace: default
+    type: MachineClasses.omni.sidero.dev
+    id: workers
+    labels:
+        team: infra
+spec:
+    matchlabels: ["omni.sidero.dev/arch = amd64"]
+`)
+	require.False(t, diags.HasError())
+
+	current := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	current.Metadata().SetVersion(current.Metadata().Version().Next())
+	current.Metadata().Labels().Set("team", "infra")
+	current.Metadata().Labels().Set(omni.SystemLabelPrefix+"managed", "")
+	current.TypedSpec().Value.MatchLabels = []string{"omni.sidero.dev/arch = amd64"}
+
+	diff, err := remoteChange(applied[0], current)
+	require.NoError(t, err)
+	require.Empty(t, diff)
+
+	current.Metadata().Labels().Set("team", "platform")
+
+	diff, err = remoteChange(applied[0], current)
+	require.NoError(t, err)
+	require.Contains(t, diff, "+    team: platform")
+}
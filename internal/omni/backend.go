@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+
+	"github.com/siderolabs/omni/client/api/omni/management"
+	managementclient "github.com/siderolabs/omni/client/pkg/client/management"
+)
+
+// managementClient is the part of the Omni management API used by the resources and data sources.
+//
+// Cluster scoped calls take the cluster name as an argument, so implementations do not need to
+// carry the cluster context like the Omni client does.
+type managementClient interface {
+	CreateSchematic(ctx context.Context, req *management.CreateSchematicRequest) (*management.CreateSchematicResponse, error)
+	CreateServiceAccount(ctx context.Context, name, armoredPGPPublicKey, role string, useUserRole bool) (string, error)
+	RenewServiceAccount(ctx context.Context, name, armoredPGPPublicKey string) (string, error)
+	ListServiceAccounts(ctx context.Context) ([]*management.ListServiceAccountsResponse_ServiceAccount, error)
+	DestroyServiceAccount(ctx context.Context, name string) error
+	KubernetesUpgradePreChecks(ctx context.Context, clusterName, newVersion string) error
+	KubernetesSyncManifests(ctx context.Context, clusterName string, dryRun bool, handler managementclient.KubernetesSyncManifestHandler) error
+}
+
+var _ managementClient = clientManagement{}
+
+// clientManagement implements managementClient with the Omni client.
+type clientManagement struct {
+	*managementclient.Client
+}
+
+func (m clientManagement) KubernetesUpgradePreChecks(ctx context.Context, clusterName, newVersion string) error {
+	return m.WithCluster(clusterName).KubernetesUpgradePreChecks(ctx, newVersion)
+}
+
+func (m clientManagement) KubernetesSyncManifests(ctx context.Context, clusterName string, dryRun bool, handler managementclient.KubernetesSyncManifestHandler) error {
+	return m.WithCluster(clusterName).KubernetesSyncManifests(ctx, dryRun, handler)
+}
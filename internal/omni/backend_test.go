@@ -0,0 +1,122 @@
+package omni
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/cosi-project/runtime/pkg/state/impl/inmem"
+	"github.com/cosi-project/runtime/pkg/state/impl/namespaced"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	managementclient "github.com/siderolabs/omni/client/pkg/client/management"
+	"github.com/stretchr/testify/require"
+)
+
+// createWithPlan runs Create of the resource with the plan set from the model, like Terraform does on apply.
+func createWithPlan(t *testing.T, r resource.Resource, model any) *resource.CreateResponse {
+	t.Helper()
+
+	var schemaResp resource.SchemaResponse
+
+	r.Schema(t.Context(), resource.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	typ := schemaResp.Schema.Type().TerraformType(t.Context())
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: tftypes.NewValue(typ, nil)}
+	require.False(t, plan.Set(t.Context(), model).HasError())
+
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(typ, nil)}}
+
+	r.Create(t.Context(), resource.CreateRequest{Plan: plan}, resp)
+
+	return resp
+}
+
+// readWithState runs Read of the resource with the prior state set from the model, like Terraform does on refresh.
+func readWithState(t *testing.T, r resource.Resource, model any) *resource.ReadResponse {
+	t.Helper()
+
+	var schemaResp resource.SchemaResponse
+
+	r.Schema(t.Context(), resource.SchemaRequest{}, &schemaResp)
+	require.False(t, schemaResp.Diagnostics.HasError())
+
+	prior := tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(t.Context()), nil)}
+	require.False(t, prior.Set(t.Context(), model).HasError())
+
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: prior.Schema, Raw: prior.Raw.Copy()}}
+
+	r.Read(t.Context(), resource.ReadRequest{State: prior}, resp)
+
+	return resp
+}
+
+// newTestState returns an empty in-memory state for unit tests.
+func newTestState() state.State { //nolint:ireturn
+	return state.WrapCore(namespaced.NewState(inmem.Build))
+}
+
+// fakeManagement is a managementClient for unit tests, the calls without a handler fail.
+type fakeManagement struct {
+	createSchematic            func(req *management.CreateSchematicRequest) (*management.CreateSchematicResponse, error)
+	kubernetesUpgradePreChecks func(clusterName, newVersion string) error
+	kubernetesSyncManifests    func(clusterName string, dryRun bool) []*management.KubernetesSyncManifestResponse
+	createServiceAccount       func(name, armoredPGPPublicKey, role string, useUserRole bool) (string, error)
+}
+
+var errNotImplemented = errors.New("not implemented")
+
+func (f *fakeManagement) CreateSchematic(_ context.Context, req *management.CreateSchematicRequest) (*management.CreateSchematicResponse, error) {
+	if f.createSchematic == nil {
+		return nil, errNotImplemented
+	}
+
+	return f.createSchematic(req)
+}
+
+func (f *fakeManagement) CreateServiceAccount(_ context.Context, name, armoredPGPPublicKey, role string, useUserRole bool) (string, error) {
+	if f.createServiceAccount == nil {
+		return "", errNotImplemented
+	}
+
+	return f.createServiceAccount(name, armoredPGPPublicKey, role, useUserRole)
+}
+
+func (f *fakeManagement) RenewServiceAccount(context.Context, string, string) (string, error) {
+	return "", errNotImplemented
+}
+
+func (f *fakeManagement) ListServiceAccounts(context.Context) ([]*management.ListServiceAccountsResponse_ServiceAccount, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeManagement) DestroyServiceAccount(context.Context, string) error {
+	return errNotImplemented
+}
+
+func (f *fakeManagement) KubernetesUpgradePreChecks(_ context.Context, clusterName, newVersion string) error {
+	if f.kubernetesUpgradePreChecks == nil {
+		return errNotImplemented
+	}
+
+	return f.kubernetesUpgradePreChecks(clusterName, newVersion)
+}
+
+func (f *fakeManagement) KubernetesSyncManifests(_ context.Context, clusterName string, dryRun bool, handler managementclient.KubernetesSyncManifestHandler) error {
+	if f.kubernetesSyncManifests == nil {
+		return errNotImplemented
+	}
+
+	for _, msg := range f.kubernetesSyncManifests(clusterName, dryRun) {
+		if err := handler(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,498 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/resource/protobuf"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// recordEnvVar is the path of the cassette the provider appends the Omni API calls to.
+	recordEnvVar = "OMNI_PROVIDER_RECORD"
+	// replayEnvVar is the path of the cassette the provider serves the Omni API calls from without connecting to Omni.
+	replayEnvVar = "OMNI_PROVIDER_REPLAY"
+)
+
+const (
+	apiState      = "state"
+	apiManagement = "management"
+
+	// methodWatchEvent is an event of a recorded watch, it is replayed with the watch instead of being matched on its own.
+	methodWatchEvent = "WatchEvent"
+
+	redactedValue = "REDACTED"
+)
+
+// redactedSpecTypes are the resource types whose spec is redacted as a whole.
+var redactedSpecTypes = []resource.Type{
+	omni.ClusterSecretsType,
+	omni.ClusterMachineConfigType,
+	omni.ClusterMachineEncryptionKeyType,
+	omni.KubeconfigType,
+	omni.TalosConfigType,
+}
+
+// redactedSpecFields are the spec fields which are redacted as a whole, the Talos config patches can hold secrets in any field.
+var redactedSpecFields = map[resource.Type][]string{
+	omni.ConfigPatchType:                 {"data", "compresseddata"},
+	omni.ClusterMachineConfigPatchesType: {"patches", "compressedpatches"},
+}
+
+// redactedManagementFields are the fields of the management API messages which are redacted as a whole,
+// as they carry manifests or client configs.
+var redactedManagementFields = []string{"object", "diff", "kubeconfig", "talosconfig"}
+
+// redactedKeys are the substrings of the field names whose values are redacted, the names are compared
+// in lower case without separators.
+var redactedKeys = []string{"secret", "token", "password", "privatekey"}
+
+// redactedAssignmentRegexp matches the secret assignments inside the values, like the join token in the SideroLink kernel argument.
+var redactedAssignmentRegexp = regexp.MustCompile(`(?i)([a-z0-9_.-]*(?:secret|token|password|private_?key)[a-z0-9_.-]*=)[^&\s"']+`)
+
+// interaction is a recorded Omni API call, the cassette stores one interaction per line.
+//
+// The calls are matched by the API, method and key, the request is only kept for the reader.
+type interaction struct {
+	Session  string          `json:"session"`
+	Seq      int             `json:"seq"`
+	API      string          `json:"api"`
+	Method   string          `json:"method"`
+	Key      string          `json:"key"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    *recordedError  `json:"error,omitempty"`
+}
+
+// recordedError keeps enough of an error to return an error of the same kind on replay.
+type recordedError struct {
+	Kind    string     `json:"kind,omitempty"`
+	Code    codes.Code `json:"code,omitempty"`
+	Message string     `json:"message"`
+}
+
+const (
+	errorKindNotFound      = "not_found"
+	errorKindConflict      = "conflict"
+	errorKindOwnerConflict = "owner_conflict"
+	errorKindPhaseConflict = "phase_conflict"
+)
+
+// cassetteRecorder appends the interactions of a provider process to the cassette.
+//
+// Every process writes under its own session, so a single cassette can hold the plan and the apply runs.
+type cassetteRecorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	session string
+	seq     int
+}
+
+func newCassetteRecorder(path string) (*cassetteRecorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+
+	return &cassetteRecorder{
+		file:    file,
+		session: fmt.Sprintf("%d-%d", time.Now().UnixNano(), os.Getpid()),
+	}, nil
+}
+
+// record writes the interaction and returns its sequence number.
+func (rec *cassetteRecorder) record(i interaction) (int, error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.seq++
+
+	i.Session = rec.session
+	i.Seq = rec.seq
+
+	data, err := json.Marshal(i)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode interaction: %w", err)
+	}
+
+	if _, err = rec.file.Write(append(data, '\n')); err != nil {
+		return 0, fmt.Errorf("failed to write interaction: %w", err)
+	}
+
+	return i.Seq, nil
+}
+
+func (rec *cassetteRecorder) Close() error {
+	return rec.file.Close()
+}
+
+// cassetteReplayer serves the recorded interactions.
+//
+// A call is answered by the first unused interaction with the same API, method and key. As a cassette can hold
+// several sessions, the replayer follows all sessions which answered every call so far and responds from the
+// earliest of them, so the plan and apply runs of a recording are replayed by the matching sessions.
+type cassetteReplayer struct {
+	mu         sync.Mutex
+	sessions   []*replaySession
+	candidates []*replaySession
+}
+
+type replaySession struct {
+	interactions []interaction
+	used         []bool
+}
+
+func loadCassette(path string) (*cassetteReplayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	replayer := &cassetteReplayer{}
+	sessions := map[string]*replaySession{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var i interaction
+
+		if err = json.Unmarshal(scanner.Bytes(), &i); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette line %d: %w", line, err)
+		}
+
+		session, ok := sessions[i.Session]
+		if !ok {
+			session = &replaySession{}
+			sessions[i.Session] = session
+			replayer.sessions = append(replayer.sessions, session)
+		}
+
+		session.interactions = append(session.interactions, i)
+		session.used = append(session.used, false)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	replayer.candidates = replayer.sessions
+
+	return replayer, nil
+}
+
+// next returns the recorded interaction for the call and the events of the watch it started.
+func (replayer *cassetteReplayer) next(api, method, key string) (interaction, []interaction, error) {
+	replayer.mu.Lock()
+	defer replayer.mu.Unlock()
+
+	var (
+		matched []*replaySession
+		indexes []int
+	)
+
+	for _, session := range replayer.candidates {
+		if idx := session.find(api, method, key); idx >= 0 {
+			matched = append(matched, session)
+			indexes = append(indexes, idx)
+		}
+	}
+
+	if len(matched) == 0 {
+		return interaction{}, nil, fmt.Errorf("no recorded %s %s call for %q in the cassette", api, method, key)
+	}
+
+	for i, session := range matched {
+		session.used[indexes[i]] = true
+	}
+
+	replayer.candidates = matched
+
+	session, found := matched[0], matched[0].interactions[indexes[0]]
+
+	var events []interaction
+
+	if method == "Watch" {
+		for _, i := range session.interactions {
+			if i.Method == methodWatchEvent && i.Key == watchEventKey(found.Seq) {
+				events = append(events, i)
+			}
+		}
+	}
+
+	return found, events, nil
+}
+
+func (session *replaySession) find(api, method, key string) int {
+	for idx, i := range session.interactions {
+		if !session.used[idx] && i.API == api && i.Method == method && i.Key == key {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+func watchEventKey(seq int) string {
+	return fmt.Sprintf("watch/%d", seq)
+}
+
+// encodeError records the error, keeping the COSI error kinds and the gRPC status code.
+func encodeError(err error) *recordedError {
+	if err == nil {
+		return nil
+	}
+
+	recorded := &recordedError{Message: err.Error()}
+
+	switch {
+	case state.IsNotFoundError(err):
+		recorded.Kind = errorKindNotFound
+	case state.IsOwnerConflictError(err):
+		recorded.Kind = errorKindOwnerConflict
+	case state.IsPhaseConflictError(err):
+		recorded.Kind = errorKindPhaseConflict
+	case state.IsConflictError(err):
+		recorded.Kind = errorKindConflict
+	default:
+		if st, ok := status.FromError(err); ok {
+			recorded.Code = st.Code()
+			recorded.Message = st.Message()
+		}
+	}
+
+	return recorded
+}
+
+// decodeError returns an error of the recorded kind, ptr is the resource of the call for the conflict errors.
+func decodeError(recorded *recordedError, ptr resource.Pointer) error {
+	if recorded == nil {
+		return nil
+	}
+
+	err := errors.New(recorded.Message)
+
+	switch recorded.Kind {
+	case errorKindNotFound:
+		return replayedNotFoundError{err}
+	case errorKindConflict:
+		return replayedConflictError{err, ptr}
+	case errorKindOwnerConflict:
+		return replayedOwnerConflictError{replayedConflictError{err, ptr}}
+	case errorKindPhaseConflict:
+		return replayedPhaseConflictError{replayedConflictError{err, ptr}}
+	}
+
+	if recorded.Code != codes.OK {
+		return status.Error(recorded.Code, recorded.Message)
+	}
+
+	return err
+}
+
+//nolint:errname
+type replayedNotFoundError struct {
+	error
+}
+
+func (replayedNotFoundError) NotFoundError() {}
+
+//nolint:errname
+type replayedConflictError struct {
+	error
+
+	ptr resource.Pointer
+}
+
+func (replayedConflictError) ConflictError() {}
+
+func (e replayedConflictError) GetResource() resource.Pointer { //nolint:ireturn
+	return e.ptr
+}
+
+//nolint:errname
+type replayedOwnerConflictError struct {
+	replayedConflictError
+}
+
+func (replayedOwnerConflictError) OwnerConflictError() {}
+
+//nolint:errname
+type replayedPhaseConflictError struct {
+	replayedConflictError
+}
+
+func (replayedPhaseConflictError) PhaseConflictError() {}
+
+// marshalResource encodes the resource as YAML with the secret values redacted.
+func marshalResource(r resource.Resource) (string, error) {
+	out, err := resource.MarshalYAML(r)
+	if err != nil {
+		return "", err
+	}
+
+	var node yaml.Node
+
+	if err = node.Encode(out); err != nil {
+		return "", fmt.Errorf("failed to encode resource %s: %w", resource.String(r), err)
+	}
+
+	if slices.Contains(redactedSpecTypes, r.Metadata().Type()) {
+		redactSpec(&node)
+	} else {
+		redactSpecFields(&node, redactedSpecFields[r.Metadata().Type()])
+		redactNode(&node)
+	}
+
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode resource %s: %w", resource.String(r), err)
+	}
+
+	return string(data), nil
+}
+
+// unmarshalResource decodes a resource encoded by marshalResource.
+func unmarshalResource(data string) (resource.Resource, error) { //nolint:ireturn
+	var r protobuf.YAMLResource
+
+	if err := yaml.Unmarshal([]byte(data), &r); err != nil {
+		return nil, fmt.Errorf("failed to decode recorded resource: %w", err)
+	}
+
+	return r.Resource(), nil
+}
+
+func redactSpec(node *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "spec" {
+			node.Content[i+1] = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+	}
+}
+
+func redactSpecFields(node *yaml.Node, fields []string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "spec" {
+			redactFields(node.Content[i+1], fields)
+		}
+	}
+}
+
+// redactFields redacts the fields of the mapping node with the given names, the nested mappings are not searched.
+func redactFields(node *yaml.Node, fields []string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if slices.Contains(fields, node.Content[i].Value) {
+			node.Content[i+1] = redactedNode(node.Content[i+1])
+		}
+	}
+}
+
+// redactNode redacts the values of the secret fields and the secret assignments in the other values,
+// fields listed in wholeFields are redacted at any depth.
+func redactNode(node *yaml.Node, wholeFields ...string) {
+	switch node.Kind { //nolint:exhaustive
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if isRedactedKey(node.Content[i].Value) || slices.Contains(wholeFields, node.Content[i].Value) {
+				node.Content[i+1] = redactedNode(node.Content[i+1])
+
+				continue
+			}
+
+			redactNode(node.Content[i+1], wholeFields...)
+		}
+	case yaml.ScalarNode:
+		node.Value = redactedAssignmentRegexp.ReplaceAllString(node.Value, "${1}"+redactedValue)
+	default:
+		for _, child := range node.Content {
+			redactNode(child, wholeFields...)
+		}
+	}
+}
+
+// redactedNode returns the redacted replacement of the node, lists and mappings are emptied so they still decode.
+func redactedNode(node *yaml.Node) *yaml.Node {
+	switch node.Kind { //nolint:exhaustive
+	case yaml.SequenceNode:
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	case yaml.MappingNode:
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Style: yaml.FlowStyle}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: redactedValue}
+	}
+}
+
+// redactJSON redacts the JSON document of a management API call like the recorded resources.
+func redactJSON(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	var node yaml.Node
+
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	redactNode(&node, redactedManagementFields...)
+
+	var v any
+
+	if err := node.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode message: %w", err)
+	}
+
+	return json.Marshal(v)
+}
+
+func isRedactedKey(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+
+	return slices.ContainsFunc(redactedKeys, func(s string) bool {
+		return strings.Contains(key, s)
+	})
+}
+
+// canonicalJSON re-encodes the JSON document with sorted keys and without spaces, so it can be used as a key.
+func canonicalJSON(data []byte) (string, error) {
+	var v any
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
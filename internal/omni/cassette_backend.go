@@ -0,0 +1,712 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	managementclient "github.com/siderolabs/omni/client/pkg/client/management"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var errWatchKindNotSupported = errors.New("watching a resource kind is not supported when recording or replaying")
+
+// resourceMessage is the request or response of the state calls.
+type resourceMessage struct {
+	Resource  string   `json:"resource,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+	EventType string   `json:"event_type,omitempty"`
+}
+
+func pointerKey(ptr resource.Pointer) string {
+	return fmt.Sprintf("%s/%s/%s", ptr.Namespace(), ptr.Type(), ptr.ID())
+}
+
+func listKey(kind resource.Kind, opts ...state.ListOption) (string, error) {
+	var options state.ListOptions
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	key := fmt.Sprintf("%s/%s", kind.Namespace(), kind.Type())
+
+	if len(options.LabelQueries) > 0 {
+		data, err := json.Marshal(options.LabelQueries)
+		if err != nil {
+			return "", err
+		}
+
+		key += "?labels=" + string(data)
+	}
+
+	if options.IDQuery.Regexp != nil {
+		key += "?id=" + options.IDQuery.Regexp.String()
+	}
+
+	return key, nil
+}
+
+func encodeResource(r resource.Resource) (json.RawMessage, error) {
+	data, err := marshalResource(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(resourceMessage{Resource: data})
+}
+
+func encodeList(list resource.List) (json.RawMessage, error) {
+	msg := resourceMessage{Resources: make([]string, 0, len(list.Items))}
+
+	for _, r := range list.Items {
+		data, err := marshalResource(r)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.Resources = append(msg.Resources, data)
+	}
+
+	return json.Marshal(msg)
+}
+
+func decodeMessage(data json.RawMessage) (resourceMessage, error) {
+	var msg resourceMessage
+
+	if len(data) == 0 {
+		return msg, nil
+	}
+
+	err := json.Unmarshal(data, &msg)
+
+	return msg, err
+}
+
+// recordingState is a state.CoreState which records the calls to the underlying state.
+type recordingState struct {
+	core     state.CoreState
+	recorder *cassetteRecorder
+}
+
+var _ state.CoreState = recordingState{}
+
+func (s recordingState) record(method, key string, request json.RawMessage, response func() (json.RawMessage, error), callErr error) (int, error) {
+	i := interaction{
+		API:     apiState,
+		Method:  method,
+		Key:     key,
+		Request: request,
+		Error:   encodeError(callErr),
+	}
+
+	if callErr == nil && response != nil {
+		var err error
+
+		if i.Response, err = response(); err != nil {
+			return 0, err
+		}
+	}
+
+	return s.recorder.record(i)
+}
+
+func (s recordingState) Get(ctx context.Context, ptr resource.Pointer, opts ...state.GetOption) (resource.Resource, error) { //nolint:ireturn
+	r, err := s.core.Get(ctx, ptr, opts...)
+
+	if _, recErr := s.record("Get", pointerKey(ptr), nil, func() (json.RawMessage, error) {
+		return encodeResource(r)
+	}, err); recErr != nil {
+		return nil, recErr
+	}
+
+	return r, err
+}
+
+func (s recordingState) List(ctx context.Context, kind resource.Kind, opts ...state.ListOption) (resource.List, error) {
+	key, err := listKey(kind, opts...)
+	if err != nil {
+		return resource.List{}, err
+	}
+
+	list, err := s.core.List(ctx, kind, opts...)
+
+	if _, recErr := s.record("List", key, nil, func() (json.RawMessage, error) {
+		return encodeList(list)
+	}, err); recErr != nil {
+		return resource.List{}, recErr
+	}
+
+	return list, err
+}
+
+func (s recordingState) Create(ctx context.Context, r resource.Resource, opts ...state.CreateOption) error {
+	request, err := encodeResource(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.core.Create(ctx, r, opts...)
+
+	if _, recErr := s.record("Create", pointerKey(r.Metadata()), request, func() (json.RawMessage, error) {
+		return encodeResource(r)
+	}, err); recErr != nil {
+		return recErr
+	}
+
+	return err
+}
+
+func (s recordingState) Update(ctx context.Context, r resource.Resource, opts ...state.UpdateOption) error {
+	request, err := encodeResource(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.core.Update(ctx, r, opts...)
+
+	if _, recErr := s.record("Update", pointerKey(r.Metadata()), request, func() (json.RawMessage, error) {
+		return encodeResource(r)
+	}, err); recErr != nil {
+		return recErr
+	}
+
+	return err
+}
+
+func (s recordingState) Destroy(ctx context.Context, ptr resource.Pointer, opts ...state.DestroyOption) error {
+	err := s.core.Destroy(ctx, ptr, opts...)
+
+	if _, recErr := s.record("Destroy", pointerKey(ptr), nil, nil, err); recErr != nil {
+		return recErr
+	}
+
+	return err
+}
+
+// Watch records the watch and every event before it is delivered, so the events are in the cassette
+// even when the process exits right after the watch is done.
+func (s recordingState) Watch(ctx context.Context, ptr resource.Pointer, ch chan<- state.Event, opts ...state.WatchOption) error {
+	events := make(chan state.Event)
+
+	err := s.core.Watch(ctx, ptr, events, opts...)
+
+	seq, recErr := s.record("Watch", pointerKey(ptr), nil, nil, err)
+	if recErr != nil {
+		return recErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			var event state.Event
+
+			select {
+			case <-ctx.Done():
+				return
+			case event = <-events:
+			}
+
+			if _, err := s.recorder.record(watchEventInteraction(seq, event)); err != nil {
+				event = state.Event{Type: state.Errored, Error: err}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- event:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s recordingState) WatchKind(context.Context, resource.Kind, chan<- state.Event, ...state.WatchKindOption) error {
+	return errWatchKindNotSupported
+}
+
+func (s recordingState) WatchKindAggregated(context.Context, resource.Kind, chan<- []state.Event, ...state.WatchKindOption) error {
+	return errWatchKindNotSupported
+}
+
+func watchEventInteraction(seq int, event state.Event) interaction {
+	i := interaction{
+		API:    apiState,
+		Method: methodWatchEvent,
+		Key:    watchEventKey(seq),
+		Error:  encodeError(event.Error),
+	}
+
+	msg := resourceMessage{EventType: event.Type.String()}
+
+	if event.Resource != nil {
+		data, err := marshalResource(event.Resource)
+		if err != nil {
+			i.Error = encodeError(err)
+
+			return i
+		}
+
+		msg.Resource = data
+	}
+
+	i.Response, _ = json.Marshal(msg) //nolint:errchkjson
+
+	return i
+}
+
+// replayingState is a state.CoreState which serves the calls from a cassette.
+type replayingState struct {
+	replayer *cassetteReplayer
+}
+
+var _ state.CoreState = replayingState{}
+
+func (s replayingState) next(method, key string, ptr resource.Pointer) (resourceMessage, []interaction, error) {
+	i, events, err := s.replayer.next(apiState, method, key)
+	if err != nil {
+		return resourceMessage{}, nil, err
+	}
+
+	if err = decodeError(i.Error, ptr); err != nil {
+		return resourceMessage{}, nil, err
+	}
+
+	msg, err := decodeMessage(i.Response)
+
+	return msg, events, err
+}
+
+func (s replayingState) Get(_ context.Context, ptr resource.Pointer, _ ...state.GetOption) (resource.Resource, error) { //nolint:ireturn
+	msg, _, err := s.next("Get", pointerKey(ptr), ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalResource(msg.Resource)
+}
+
+func (s replayingState) List(_ context.Context, kind resource.Kind, opts ...state.ListOption) (resource.List, error) {
+	key, err := listKey(kind, opts...)
+	if err != nil {
+		return resource.List{}, err
+	}
+
+	msg, _, err := s.next("List", key, resource.NewMetadata(kind.Namespace(), kind.Type(), "", resource.VersionUndefined))
+	if err != nil {
+		return resource.List{}, err
+	}
+
+	list := resource.List{Items: make([]resource.Resource, 0, len(msg.Resources))}
+
+	for _, data := range msg.Resources {
+		r, err := unmarshalResource(data)
+		if err != nil {
+			return resource.List{}, err
+		}
+
+		list.Items = append(list.Items, r)
+	}
+
+	return list, nil
+}
+
+func (s replayingState) Create(_ context.Context, r resource.Resource, _ ...state.CreateOption) error {
+	msg, _, err := s.next("Create", pointerKey(r.Metadata()), r.Metadata())
+	if err != nil {
+		return err
+	}
+
+	return applyRecordedMetadata(msg, r)
+}
+
+func (s replayingState) Update(_ context.Context, r resource.Resource, _ ...state.UpdateOption) error {
+	msg, _, err := s.next("Update", pointerKey(r.Metadata()), r.Metadata())
+	if err != nil {
+		return err
+	}
+
+	return applyRecordedMetadata(msg, r)
+}
+
+func (s replayingState) Destroy(_ context.Context, ptr resource.Pointer, _ ...state.DestroyOption) error {
+	_, _, err := s.next("Destroy", pointerKey(ptr), ptr)
+
+	return err
+}
+
+// Watch sends the recorded events, the watch stays open until the context is canceled like a real watch.
+func (s replayingState) Watch(ctx context.Context, ptr resource.Pointer, ch chan<- state.Event, _ ...state.WatchOption) error {
+	_, recorded, err := s.next("Watch", pointerKey(ptr), ptr)
+	if err != nil {
+		return err
+	}
+
+	events := make([]state.Event, 0, len(recorded))
+
+	for _, i := range recorded {
+		event, err := replayWatchEvent(i, ptr)
+		if err != nil {
+			return err
+		}
+
+		events = append(events, event)
+	}
+
+	go func() {
+		for _, event := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- event:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s replayingState) WatchKind(context.Context, resource.Kind, chan<- state.Event, ...state.WatchKindOption) error {
+	return errWatchKindNotSupported
+}
+
+func (s replayingState) WatchKindAggregated(context.Context, resource.Kind, chan<- []state.Event, ...state.WatchKindOption) error {
+	return errWatchKindNotSupported
+}
+
+func replayWatchEvent(i interaction, ptr resource.Pointer) (state.Event, error) {
+	msg, err := decodeMessage(i.Response)
+	if err != nil {
+		return state.Event{}, err
+	}
+
+	event := state.Event{Error: decodeError(i.Error, ptr)}
+
+	if event.Type, err = parseEventType(msg.EventType); err != nil {
+		return state.Event{}, err
+	}
+
+	if msg.Resource != "" {
+		if event.Resource, err = unmarshalResource(msg.Resource); err != nil {
+			return state.Event{}, err
+		}
+	}
+
+	return event, nil
+}
+
+func parseEventType(s string) (state.EventType, error) {
+	for eventType := state.Created; eventType <= state.Noop; eventType++ {
+		if eventType.String() == s {
+			return eventType, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown watch event type %q", s)
+}
+
+// applyRecordedMetadata updates the resource metadata like the Omni client does after a create or update.
+func applyRecordedMetadata(msg resourceMessage, r resource.Resource) error {
+	recorded, err := unmarshalResource(msg.Resource)
+	if err != nil {
+		return err
+	}
+
+	r.Metadata().SetVersion(recorded.Metadata().Version())
+	r.Metadata().SetUpdated(recorded.Metadata().Updated())
+
+	return r.Metadata().SetOwner(recorded.Metadata().Owner())
+}
+
+// recordingManagement is a managementClient which records the calls to the underlying client.
+type recordingManagement struct {
+	client   managementClient
+	recorder *cassetteRecorder
+}
+
+var _ managementClient = recordingManagement{}
+
+// managementKey encodes the request of a management API call with the secrets redacted, the key matches the call on replay.
+func managementKey(request any) (json.RawMessage, string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if data, err = redactJSON(data); err != nil {
+		return nil, "", err
+	}
+
+	key, err := canonicalJSON(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, key, nil
+}
+
+// record writes the call, the response is kept even if the call failed, e.g. for the messages of a failed stream.
+// The request and the response are redacted like the recorded resources.
+func (m recordingManagement) record(method string, request any, response json.RawMessage, callErr error) error {
+	data, key, err := managementKey(request)
+	if err != nil {
+		return err
+	}
+
+	if response, err = redactJSON(response); err != nil {
+		return err
+	}
+
+	_, err = m.recorder.record(interaction{
+		API:      apiManagement,
+		Method:   method,
+		Key:      key,
+		Request:  data,
+		Response: response,
+		Error:    encodeError(callErr),
+	})
+
+	return err
+}
+
+func (m recordingManagement) CreateSchematic(ctx context.Context, req *management.CreateSchematicRequest) (*management.CreateSchematicResponse, error) {
+	resp, err := m.client.CreateSchematic(ctx, req)
+
+	if recErr := m.record("CreateSchematic", protoMessage{req}, marshalProto(resp), err); recErr != nil {
+		return nil, recErr
+	}
+
+	return resp, err
+}
+
+func (m recordingManagement) CreateServiceAccount(ctx context.Context, name, armoredPGPPublicKey, role string, useUserRole bool) (string, error) {
+	publicKeyID, err := m.client.CreateServiceAccount(ctx, name, armoredPGPPublicKey, role, useUserRole)
+
+	if recErr := m.record("CreateServiceAccount", serviceAccountRequest{Name: name, Role: role, UseUserRole: useUserRole},
+		marshalJSON(serviceAccountResponse{PublicKeyID: publicKeyID}), err); recErr != nil {
+		return "", recErr
+	}
+
+	return publicKeyID, err
+}
+
+func (m recordingManagement) RenewServiceAccount(ctx context.Context, name, armoredPGPPublicKey string) (string, error) {
+	publicKeyID, err := m.client.RenewServiceAccount(ctx, name, armoredPGPPublicKey)
+
+	if recErr := m.record("RenewServiceAccount", serviceAccountRequest{Name: name},
+		marshalJSON(serviceAccountResponse{PublicKeyID: publicKeyID}), err); recErr != nil {
+		return "", recErr
+	}
+
+	return publicKeyID, err
+}
+
+func (m recordingManagement) ListServiceAccounts(ctx context.Context) ([]*management.ListServiceAccountsResponse_ServiceAccount, error) {
+	accounts, err := m.client.ListServiceAccounts(ctx)
+
+	if recErr := m.record("ListServiceAccounts", struct{}{},
+		marshalProto(&management.ListServiceAccountsResponse{ServiceAccounts: accounts}), err); recErr != nil {
+		return nil, recErr
+	}
+
+	return accounts, err
+}
+
+func (m recordingManagement) DestroyServiceAccount(ctx context.Context, name string) error {
+	err := m.client.DestroyServiceAccount(ctx, name)
+
+	if recErr := m.record("DestroyServiceAccount", serviceAccountRequest{Name: name}, nil, err); recErr != nil {
+		return recErr
+	}
+
+	return err
+}
+
+func (m recordingManagement) KubernetesUpgradePreChecks(ctx context.Context, clusterName, newVersion string) error {
+	err := m.client.KubernetesUpgradePreChecks(ctx, clusterName, newVersion)
+
+	if recErr := m.record("KubernetesUpgradePreChecks", clusterRequest{Cluster: clusterName, Version: newVersion}, nil, err); recErr != nil {
+		return recErr
+	}
+
+	return err
+}
+
+// KubernetesSyncManifests records the streamed messages together with the error the stream ended with.
+func (m recordingManagement) KubernetesSyncManifests(ctx context.Context, clusterName string, dryRun bool, handler managementclient.KubernetesSyncManifestHandler) error {
+	var messages []json.RawMessage
+
+	err := m.client.KubernetesSyncManifests(ctx, clusterName, dryRun, func(resp *management.KubernetesSyncManifestResponse) error {
+		messages = append(messages, marshalProto(resp))
+
+		return handler(resp)
+	})
+
+	if recErr := m.record("KubernetesSyncManifests", clusterRequest{Cluster: clusterName, DryRun: dryRun}, marshalJSON(messages), err); recErr != nil {
+		return recErr
+	}
+
+	return err
+}
+
+// replayingManagement is a managementClient which serves the calls from a cassette.
+type replayingManagement struct {
+	replayer *cassetteReplayer
+}
+
+var _ managementClient = replayingManagement{}
+
+// next decodes the recorded response and returns the recorded error of the call.
+func (m replayingManagement) next(method string, request, response any) error {
+	_, key, err := managementKey(request)
+	if err != nil {
+		return err
+	}
+
+	i, _, err := m.replayer.next(apiManagement, method, key)
+	if err != nil {
+		return err
+	}
+
+	if response != nil && len(i.Response) > 0 {
+		if msg, ok := response.(proto.Message); ok {
+			err = protojson.Unmarshal(i.Response, msg)
+		} else {
+			err = json.Unmarshal(i.Response, response)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to decode recorded %s response: %w", method, err)
+		}
+	}
+
+	return decodeError(i.Error, nil)
+}
+
+func (m replayingManagement) CreateSchematic(_ context.Context, req *management.CreateSchematicRequest) (*management.CreateSchematicResponse, error) {
+	var resp management.CreateSchematicResponse
+
+	if err := m.next("CreateSchematic", protoMessage{req}, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (m replayingManagement) CreateServiceAccount(_ context.Context, name, _, role string, useUserRole bool) (string, error) {
+	var resp serviceAccountResponse
+
+	if err := m.next("CreateServiceAccount", serviceAccountRequest{Name: name, Role: role, UseUserRole: useUserRole}, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.PublicKeyID, nil
+}
+
+func (m replayingManagement) RenewServiceAccount(_ context.Context, name, _ string) (string, error) {
+	var resp serviceAccountResponse
+
+	if err := m.next("RenewServiceAccount", serviceAccountRequest{Name: name}, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.PublicKeyID, nil
+}
+
+func (m replayingManagement) ListServiceAccounts(context.Context) ([]*management.ListServiceAccountsResponse_ServiceAccount, error) {
+	var resp management.ListServiceAccountsResponse
+
+	if err := m.next("ListServiceAccounts", struct{}{}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.ServiceAccounts, nil
+}
+
+func (m replayingManagement) DestroyServiceAccount(_ context.Context, name string) error {
+	return m.next("DestroyServiceAccount", serviceAccountRequest{Name: name}, nil)
+}
+
+func (m replayingManagement) KubernetesUpgradePreChecks(_ context.Context, clusterName, newVersion string) error {
+	return m.next("KubernetesUpgradePreChecks", clusterRequest{Cluster: clusterName, Version: newVersion}, nil)
+}
+
+// KubernetesSyncManifests sends the recorded messages to the handler before returning the recorded error.
+func (m replayingManagement) KubernetesSyncManifests(_ context.Context, clusterName string, dryRun bool, handler managementclient.KubernetesSyncManifestHandler) error {
+	var messages []json.RawMessage
+
+	callErr := m.next("KubernetesSyncManifests", clusterRequest{Cluster: clusterName, DryRun: dryRun}, &messages)
+	if callErr != nil && len(messages) == 0 {
+		return callErr
+	}
+
+	for _, data := range messages {
+		var resp management.KubernetesSyncManifestResponse
+
+		if err := protojson.Unmarshal(data, &resp); err != nil {
+			return err
+		}
+
+		if err := handler(&resp); err != nil {
+			return err
+		}
+	}
+
+	return callErr
+}
+
+type serviceAccountRequest struct {
+	Name        string `json:"name"`
+	Role        string `json:"role,omitempty"`
+	UseUserRole bool   `json:"use_user_role,omitempty"`
+}
+
+type serviceAccountResponse struct {
+	PublicKeyID string `json:"public_key_id"`
+}
+
+type clusterRequest struct {
+	Cluster string `json:"cluster"`
+	Version string `json:"version,omitempty"`
+	DryRun  bool   `json:"dry_run,omitempty"`
+}
+
+// protoMessage encodes the message with protojson when it is marshaled to JSON.
+type protoMessage struct {
+	proto.Message
+}
+
+func (m protoMessage) MarshalJSON() ([]byte, error) {
+	return protojson.Marshal(m.Message)
+}
+
+func marshalProto(msg proto.Message) json.RawMessage {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+func marshalJSON(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
@@ -0,0 +1,304 @@
+package omni
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingProvider returns a provider which records the calls to the in-memory state and management client.
+func recordingProvider(t *testing.T, path string, st state.State, mgmt managementClient) *omniProvider {
+	t.Helper()
+
+	recorder, err := newCassetteRecorder(path)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, recorder.Close()) })
+
+	return &omniProvider{
+		state:      state.WrapCore(recordingState{core: st, recorder: recorder}),
+		management: recordingManagement{client: mgmt, recorder: recorder},
+	}
+}
+
+func replayingProvider(t *testing.T, path string) *omniProvider {
+	t.Helper()
+
+	replayer, err := loadCassette(path)
+	require.NoError(t, err)
+
+	return &omniProvider{
+		state:      state.WrapCore(replayingState{replayer: replayer}),
+		management: replayingManagement{replayer: replayer},
+	}
+}
+
+func TestCassetteReplaysState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	// run the same calls against the recording and replaying providers
+	run := func(p *omniProvider) {
+		r := &etcdBackupS3ConfigResource{provider: p}
+
+		plan := etcdBackupS3ConfigResourceModel{
+			Bucket:          types.StringValue("omni-backups"),
+			AccessKeyID:     types.StringValue("access"),
+			SecretAccessKey: types.StringValue("s3cr3t-value"),
+		}
+
+		require.NoError(t, r.apply(t.Context(), plan))
+
+		plan.Bucket = types.StringValue("omni-backups-2")
+
+		require.NoError(t, r.apply(t.Context(), plan))
+
+		conf, err := safe.StateGetByID[*omni.EtcdBackupS3Conf](t.Context(), p.state, omni.EtcdBackupS3ConfID)
+		require.NoError(t, err)
+		require.Equal(t, "omni-backups-2", conf.TypedSpec().Value.Bucket)
+		require.Equal(t, resource.VersionUndefined.Next().Next(), conf.Metadata().Version())
+
+		classes, err := safe.StateListAll[*omni.MachineClass](t.Context(), p.state)
+		require.NoError(t, err)
+		require.Equal(t, 1, classes.Len())
+		require.Equal(t, "workers", classes.Get(0).Metadata().ID())
+
+		_, err = p.state.Get(t.Context(), omni.NewMachineClass(resources.DefaultNamespace, "missing").Metadata())
+		require.True(t, state.IsNotFoundError(err))
+
+		require.NoError(t, p.state.Destroy(t.Context(), omni.NewMachineClass(resources.DefaultNamespace, "workers").Metadata()))
+
+		err = p.state.Create(t.Context(), omni.NewEtcdBackupS3Conf())
+		require.True(t, state.IsConflictError(err))
+	}
+
+	st := newTestState()
+
+	class := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	class.TypedSpec().Value.MatchLabels = []string{"omni.sidero.dev/arch = amd64"}
+	require.NoError(t, st.Create(t.Context(), class))
+
+	run(recordingProvider(t, path, st, &fakeManagement{}))
+
+	cassette, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(cassette), "s3cr3t-value")
+	require.Contains(t, string(cassette), redactedValue)
+
+	run(replayingProvider(t, path))
+}
+
+func TestCassetteReplaysWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	st := newTestState()
+
+	watch := func(p *omniProvider) {
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+
+		r, err := p.state.WatchFor(ctx, omni.NewEtcdBackupStatus("talos").Metadata(), state.WithCondition(func(r resource.Resource) (bool, error) {
+			status, ok := r.(*omni.EtcdBackupStatus)
+
+			return ok && status.TypedSpec().Value.Status == specs.EtcdBackupStatusSpec_Ok, nil
+		}))
+		require.NoError(t, err)
+		require.Equal(t, "talos", r.Metadata().ID())
+	}
+
+	recorded := recordingProvider(t, path, st, &fakeManagement{})
+
+	backupStatus := omni.NewEtcdBackupStatus("talos")
+	backupStatus.TypedSpec().Value.Status = specs.EtcdBackupStatusSpec_Running
+	require.NoError(t, st.Create(t.Context(), backupStatus))
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+
+		_, err := safe.StateUpdateWithConflicts(context.Background(), st, backupStatus.Metadata(), func(r *omni.EtcdBackupStatus) error {
+			r.TypedSpec().Value.Status = specs.EtcdBackupStatusSpec_Ok
+
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	watch(recorded)
+	watch(replayingProvider(t, path))
+}
+
+func TestCassetteReplaysManagement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	mgmt := &fakeManagement{
+		createSchematic: func(req *management.CreateSchematicRequest) (*management.CreateSchematicResponse, error) {
+			return &management.CreateSchematicResponse{SchematicId: "schematic-" + req.TalosVersion}, nil
+		},
+		kubernetesUpgradePreChecks: func(string, string) error {
+			return status.Error(codes.FailedPrecondition, "pre-checks failed")
+		},
+		kubernetesSyncManifests: func(string, bool) []*management.KubernetesSyncManifestResponse {
+			return []*management.KubernetesSyncManifestResponse{
+				{ResponseType: management.KubernetesSyncManifestResponse_MANIFEST, Path: "a.yaml", Diff: "-a\n+b"},
+				{ResponseType: management.KubernetesSyncManifestResponse_MANIFEST, Path: "b.yaml"},
+			}
+		},
+	}
+
+	run := func(p *omniProvider) {
+		resp, err := p.management.CreateSchematic(t.Context(), &management.CreateSchematicRequest{TalosVersion: "1.9.5"})
+		require.NoError(t, err)
+		require.Equal(t, "schematic-1.9.5", resp.SchematicId)
+
+		err = p.management.KubernetesUpgradePreChecks(t.Context(), "talos", "1.32.0")
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+		var paths []string
+
+		require.NoError(t, p.management.KubernetesSyncManifests(t.Context(), "talos", true, func(resp *management.KubernetesSyncManifestResponse) error {
+			paths = append(paths, resp.Path)
+
+			return nil
+		}))
+		require.Equal(t, []string{"a.yaml", "b.yaml"}, paths)
+
+		_, err = p.management.ListServiceAccounts(t.Context())
+		require.EqualError(t, err, errNotImplemented.Error())
+	}
+
+	run(recordingProvider(t, path, newTestState(), mgmt))
+	run(replayingProvider(t, path))
+
+	_, err := replayingProvider(t, path).management.CreateSchematic(t.Context(), &management.CreateSchematicRequest{TalosVersion: "1.10.0"})
+	require.ErrorContains(t, err, "no recorded management CreateSchematic call")
+}
+
+func TestCassetteReplaysSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	st := newTestState()
+	ptr := omni.NewMachineClass(resources.DefaultNamespace, "workers").Metadata()
+
+	// a plan which finds nothing and an apply which creates the resource
+	plan := recordingProvider(t, path, st, &fakeManagement{})
+
+	_, err := plan.state.Get(t.Context(), ptr)
+	require.True(t, state.IsNotFoundError(err))
+
+	apply := recordingProvider(t, path, st, &fakeManagement{})
+
+	_, err = apply.state.Get(t.Context(), ptr)
+	require.True(t, state.IsNotFoundError(err))
+	require.NoError(t, apply.state.Create(t.Context(), omni.NewMachineClass(resources.DefaultNamespace, "workers")))
+
+	_, err = apply.state.Get(t.Context(), ptr)
+	require.NoError(t, err)
+
+	replayed := replayingProvider(t, path)
+
+	_, err = replayed.state.Get(t.Context(), ptr)
+	require.True(t, state.IsNotFoundError(err))
+	require.NoError(t, replayed.state.Create(t.Context(), omni.NewMachineClass(resources.DefaultNamespace, "workers")))
+
+	_, err = replayed.state.Get(t.Context(), ptr)
+	require.NoError(t, err)
+}
+
+func TestMarshalResourceRedactsSecrets(t *testing.T) {
+	conf := omni.NewEtcdBackupS3Conf()
+	conf.TypedSpec().Value.Bucket = "omni-backups"
+	conf.TypedSpec().Value.SecretAccessKey = "secret"
+	conf.TypedSpec().Value.SessionToken = "token"
+
+	data, err := marshalResource(conf)
+	require.NoError(t, err)
+
+	r, err := unmarshalResource(data)
+	require.NoError(t, err)
+
+	spec := r.(*omni.EtcdBackupS3Conf).TypedSpec().Value //nolint:forcetypeassert
+	require.Equal(t, "omni-backups", spec.Bucket)
+	require.Equal(t, redactedValue, spec.SecretAccessKey)
+	require.Equal(t, redactedValue, spec.SessionToken)
+
+	secrets := omni.NewClusterSecrets(resources.DefaultNamespace, "talos")
+	secrets.TypedSpec().Value.Data = []byte("bundle")
+
+	data, err = marshalResource(secrets)
+	require.NoError(t, err)
+
+	r, err = unmarshalResource(data)
+	require.NoError(t, err)
+	require.Empty(t, r.(*omni.ClusterSecrets).TypedSpec().Value.Data) //nolint:forcetypeassert
+
+	patch := omni.NewConfigPatch(resources.DefaultNamespace, "500-install")
+	patch.TypedSpec().Value.Data = "machine:\n  install:\n    disk: /dev/sda\n"
+
+	data, err = marshalResource(patch)
+	require.NoError(t, err)
+	require.NotContains(t, data, "/dev/sda")
+
+	r, err = unmarshalResource(data)
+	require.NoError(t, err)
+	require.Equal(t, redactedValue, r.(*omni.ConfigPatch).TypedSpec().Value.Data) //nolint:forcetypeassert
+	require.Empty(t, r.(*omni.ConfigPatch).TypedSpec().Value.CompressedData)      //nolint:forcetypeassert
+}
+
+func TestCassetteRedactsManagement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	mgmt := &fakeManagement{
+		createSchematic: func(*management.CreateSchematicRequest) (*management.CreateSchematicResponse, error) {
+			return &management.CreateSchematicResponse{SchematicId: "schematic"}, nil
+		},
+		kubernetesSyncManifests: func(string, bool) []*management.KubernetesSyncManifestResponse {
+			return []*management.KubernetesSyncManifestResponse{
+				{ResponseType: management.KubernetesSyncManifestResponse_MANIFEST, Path: "secret.yaml", Object: []byte("kind: Secret"), Diff: "+password: hunter2"},
+			}
+		},
+	}
+
+	request := &management.CreateSchematicRequest{
+		TalosVersion:    "1.9.5",
+		ExtraKernelArgs: []string{"siderolink.api=https://omni.example.com?jointoken=join-secret", "console=ttyS0"},
+	}
+
+	run := func(p *omniProvider) {
+		resp, err := p.management.CreateSchematic(t.Context(), request)
+		require.NoError(t, err)
+		require.Equal(t, "schematic", resp.SchematicId)
+
+		var paths []string
+
+		require.NoError(t, p.management.KubernetesSyncManifests(t.Context(), "talos", true, func(resp *management.KubernetesSyncManifestResponse) error {
+			paths = append(paths, resp.Path)
+
+			return nil
+		}))
+		require.Equal(t, []string{"secret.yaml"}, paths)
+	}
+
+	run(recordingProvider(t, path, newTestState(), mgmt))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "join-secret")
+	require.NotContains(t, string(data), "hunter2")
+	require.Contains(t, string(data), "jointoken="+redactedValue)
+	require.Contains(t, string(data), "console=ttyS0")
+
+	run(replayingProvider(t, path))
+}
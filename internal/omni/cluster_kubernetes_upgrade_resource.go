@@ -0,0 +1,339 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cosi_res "github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+const defaultUpgradeTimeout = "30m"
+
+var (
+	_ resource.Resource                = &clusterKubernetesUpgradeResource{}
+	_ resource.ResourceWithModifyPlan  = &clusterKubernetesUpgradeResource{}
+	_ resource.ResourceWithImportState = &clusterKubernetesUpgradeResource{}
+)
+
+func NewClusterKubernetesUpgradeResource() resource.Resource {
+	return &clusterKubernetesUpgradeResource{}
+}
+
+type clusterKubernetesUpgradeResource struct {
+	provider *omniProvider
+}
+
+type clusterKubernetesUpgradeResourceModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Cluster            types.String `tfsdk:"cluster"`
+	KubernetesVersion  types.String `tfsdk:"kubernetes_version"`
+	Timeout            types.String `tfsdk:"timeout"`
+	LastUpgradeVersion types.String `tfsdk:"last_upgrade_version"`
+}
+
+func (r *clusterKubernetesUpgradeResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cluster_kubernetes_upgrade"
+}
+
+func (r *clusterKubernetesUpgradeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Upgrades the Kubernetes version of a cluster. The upgrade pre-checks run during plan and before the upgrade, " +
+			"then the upgrade is followed until it completes or fails. Destroying the resource leaves the cluster version as is.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cluster": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster to upgrade",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"kubernetes_version": schema.StringAttribute{
+				MarkdownDescription: "The Kubernetes version of the cluster, e.g. '1.32.3'",
+				Required:            true,
+			},
+			"timeout": schema.StringAttribute{
+				MarkdownDescription: "How long to wait for the upgrade to complete as a Go duration. Defaults to '" + defaultUpgradeTimeout + "'",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(defaultUpgradeTimeout),
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"last_upgrade_version": schema.StringAttribute{
+				MarkdownDescription: "The version of the last successful upgrade reported by Omni",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (r *clusterKubernetesUpgradeResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+// ModifyPlan runs the upgrade pre-checks when the planned version differs from the current one.
+func (r *clusterKubernetesUpgradeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || r.provider == nil {
+		return
+	}
+
+	var plan clusterKubernetesUpgradeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Cluster.IsUnknown() || plan.KubernetesVersion.IsUnknown() {
+		return
+	}
+
+	cluster, err := safe.StateGetByID[*omni.Cluster](ctx, r.provider.state, plan.Cluster.ValueString())
+	if err != nil {
+		// The cluster may be created in the same apply
+		if state.IsNotFoundError(err) {
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read cluster '%s': %v", plan.Cluster.ValueString(), err))
+		return
+	}
+
+	version := strings.TrimPrefix(plan.KubernetesVersion.ValueString(), "v")
+	if cluster.TypedSpec().Value.KubernetesVersion == version {
+		return
+	}
+
+	if err := r.provider.management.KubernetesUpgradePreChecks(ctx, plan.Cluster.ValueString(), version); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("kubernetes_version"),
+			"Kubernetes Upgrade Pre-Checks Failed",
+			fmt.Sprintf("Upgrading cluster '%s' to Kubernetes %s is not possible: %s", plan.Cluster.ValueString(), version, err),
+		)
+	}
+}
+
+func (r *clusterKubernetesUpgradeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan clusterKubernetesUpgradeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	last, err := r.upgrade(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	resp.Diagnostics.AddWarning("Kubernetes Upgrade Status", fmt.Sprintf("Cluster '%s' upgrade: %s", plan.Cluster.ValueString(), kubernetesUpgradeSummary(last)))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *clusterKubernetesUpgradeResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	st := r.provider.state
+
+	var tfState clusterKubernetesUpgradeResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cluster, err := safe.StateGetByID[*omni.Cluster](ctx, st, tfState.ID.ValueString())
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read cluster '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	tfState.Cluster = tfState.ID
+	if strings.TrimPrefix(tfState.KubernetesVersion.ValueString(), "v") != cluster.TypedSpec().Value.KubernetesVersion {
+		tfState.KubernetesVersion = types.StringValue(cluster.TypedSpec().Value.KubernetesVersion)
+	}
+
+	if tfState.Timeout.IsNull() {
+		tfState.Timeout = types.StringValue(defaultUpgradeTimeout)
+	}
+
+	upgradeStatus, err := safe.StateGetByID[*omni.KubernetesUpgradeStatus](ctx, st, tfState.ID.ValueString())
+	if err != nil && !state.IsNotFoundError(err) {
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read upgrade status of cluster '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	tfState.LastUpgradeVersion = types.StringValue("")
+	if upgradeStatus != nil {
+		tfState.LastUpgradeVersion = types.StringValue(upgradeStatus.TypedSpec().Value.LastUpgradeVersion)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, tfState)...)
+}
+
+func (r *clusterKubernetesUpgradeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan clusterKubernetesUpgradeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	last, err := r.upgrade(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, err.Error())
+		return
+	}
+
+	resp.Diagnostics.AddWarning("Kubernetes Upgrade Status", fmt.Sprintf("Cluster '%s' upgrade: %s", plan.Cluster.ValueString(), kubernetesUpgradeSummary(last)))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Delete only removes the resource from the state, Kubernetes can't be downgraded.
+func (r *clusterKubernetesUpgradeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+func (r *clusterKubernetesUpgradeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// upgrade runs the pre-checks, sets the new version on the cluster and waits for the upgrade to finish.
+// The configured version is kept in the plan, the version without the 'v' prefix is only used for Omni.
+// It returns the last upgrade status observed.
+func (r *clusterKubernetesUpgradeResource) upgrade(ctx context.Context, plan *clusterKubernetesUpgradeResourceModel) (*specs.KubernetesUpgradeStatusSpec, error) {
+	st := r.provider.state
+	clusterName := plan.Cluster.ValueString()
+	version := strings.TrimPrefix(plan.KubernetesVersion.ValueString(), "v")
+
+	timeout, err := time.ParseDuration(plan.Timeout.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout '%s': %v", plan.Timeout.ValueString(), err)
+	}
+
+	cluster, err := safe.StateGetByID[*omni.Cluster](ctx, st, clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster '%s': %v", clusterName, err)
+	}
+
+	if cluster.TypedSpec().Value.KubernetesVersion != version {
+		if err := r.provider.management.KubernetesUpgradePreChecks(ctx, clusterName, version); err != nil {
+			return nil, fmt.Errorf("upgrade pre-checks for Kubernetes %s failed: %v", version, err)
+		}
+
+		if _, err := safe.StateUpdateWithConflicts(ctx, st, cluster.Metadata(), func(res *omni.Cluster) error {
+			res.TypedSpec().Value.KubernetesVersion = version
+
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("failed to set Kubernetes version of cluster '%s': %v", clusterName, err)
+		}
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var last *specs.KubernetesUpgradeStatusSpec
+
+	_, err = st.WatchFor(watchCtx, omni.NewKubernetesUpgradeStatus(resources.DefaultNamespace, clusterName).Metadata(),
+		state.WithCondition(func(res cosi_res.Resource) (bool, error) {
+			upgradeStatus, ok := res.(*omni.KubernetesUpgradeStatus)
+			if !ok {
+				return false, nil
+			}
+
+			last = upgradeStatus.TypedSpec().Value
+
+			tflog.Info(ctx, "kubernetes upgrade progress", map[string]any{
+				"cluster": clusterName,
+				"phase":   last.Phase.String(),
+				"step":    last.Step,
+				"status":  last.Status,
+			})
+
+			return kubernetesUpgradeFinished(last, version)
+		}),
+	)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return last, fmt.Errorf("timed out waiting for the upgrade of cluster '%s' to Kubernetes %s, %s", clusterName, version, kubernetesUpgradeSummary(last))
+		}
+
+		return last, fmt.Errorf("upgrade of cluster '%s' to Kubernetes %s failed: %v, %s", clusterName, version, err, kubernetesUpgradeSummary(last))
+	}
+
+	plan.ID = plan.Cluster
+	plan.LastUpgradeVersion = types.StringValue(last.LastUpgradeVersion)
+
+	return last, nil
+}
+
+// Helper functions
+func kubernetesUpgradeFinished(status *specs.KubernetesUpgradeStatusSpec, version string) (bool, error) {
+	switch status.Phase {
+	case specs.KubernetesUpgradeStatusSpec_Done:
+		return status.LastUpgradeVersion == version, nil
+	case specs.KubernetesUpgradeStatusSpec_Failed, specs.KubernetesUpgradeStatusSpec_Reverting:
+		if status.CurrentUpgradeVersion != "" && status.CurrentUpgradeVersion != version {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("phase %s at step '%s': %s", status.Phase, status.Step, status.Error)
+	default:
+		return false, nil
+	}
+}
+
+func kubernetesUpgradeSummary(status *specs.KubernetesUpgradeStatusSpec) string {
+	if status == nil {
+		return "no upgrade status was reported"
+	}
+
+	summary := fmt.Sprintf("last phase %s, step '%s', status '%s'", status.Phase, status.Step, status.Status)
+	if status.Error != "" {
+		summary += fmt.Sprintf(", error '%s'", status.Error)
+	}
+
+	return summary
+}
@@ -0,0 +1,109 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterKubernetesUpgradeResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_cluster_kubernetes_upgrade" "test" {
+  cluster            = "talos-default"
+  kubernetes_version = "1.32.3"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_cluster_kubernetes_upgrade.test", "id", "talos-default"),
+					resource.TestCheckResourceAttr("omni_cluster_kubernetes_upgrade.test", "last_upgrade_version", "1.32.3"),
+				),
+			},
+		},
+	})
+}
+
+func TestKubernetesUpgradeFinished(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   *specs.KubernetesUpgradeStatusSpec
+		finished bool
+		err      string
+	}{
+		{
+			name:   "upgrading",
+			status: &specs.KubernetesUpgradeStatusSpec{Phase: specs.KubernetesUpgradeStatusSpec_Upgrading, CurrentUpgradeVersion: "1.32.3"},
+		},
+		{
+			name:   "done with previous version",
+			status: &specs.KubernetesUpgradeStatusSpec{Phase: specs.KubernetesUpgradeStatusSpec_Done, LastUpgradeVersion: "1.32.2"},
+		},
+		{
+			name:     "done",
+			status:   &specs.KubernetesUpgradeStatusSpec{Phase: specs.KubernetesUpgradeStatusSpec_Done, LastUpgradeVersion: "1.32.3"},
+			finished: true,
+		},
+		{
+			name:   "failed",
+			status: &specs.KubernetesUpgradeStatusSpec{Phase: specs.KubernetesUpgradeStatusSpec_Failed, CurrentUpgradeVersion: "1.32.3", Step: "kube-apiserver", Error: "boom"},
+			err:    "phase Failed at step 'kube-apiserver': boom",
+		},
+		{
+			name:   "previous failure",
+			status: &specs.KubernetesUpgradeStatusSpec{Phase: specs.KubernetesUpgradeStatusSpec_Failed, CurrentUpgradeVersion: "1.32.2", Error: "boom"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			finished, err := kubernetesUpgradeFinished(tc.status, "1.32.3")
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.finished, finished)
+		})
+	}
+}
+
+func TestClusterKubernetesUpgradeKeepsConfiguredVersion(t *testing.T) {
+	st := newTestState()
+
+	cluster := omni.NewCluster(resources.DefaultNamespace, "talos-default")
+	cluster.TypedSpec().Value.KubernetesVersion = "1.32.3"
+	require.NoError(t, st.Create(t.Context(), cluster))
+
+	upgradeStatus := omni.NewKubernetesUpgradeStatus(resources.DefaultNamespace, "talos-default")
+	upgradeStatus.TypedSpec().Value.Phase = specs.KubernetesUpgradeStatusSpec_Done
+	upgradeStatus.TypedSpec().Value.LastUpgradeVersion = "1.32.3"
+	upgradeStatus.TypedSpec().Value.Status = "upgrade successful"
+	require.NoError(t, st.Create(t.Context(), upgradeStatus))
+
+	r := &clusterKubernetesUpgradeResource{provider: &omniProvider{state: st, management: &fakeManagement{}}}
+
+	resp := createWithPlan(t, r, clusterKubernetesUpgradeResourceModel{
+		ID:                 types.StringUnknown(),
+		Cluster:            types.StringValue("talos-default"),
+		KubernetesVersion:  types.StringValue("v1.32.3"),
+		Timeout:            types.StringValue("1m"),
+		LastUpgradeVersion: types.StringUnknown(),
+	})
+	require.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+	require.Len(t, resp.Diagnostics.Warnings(), 1)
+	require.Contains(t, resp.Diagnostics.Warnings()[0].Detail(), "status 'upgrade successful'")
+
+	var saved clusterKubernetesUpgradeResourceModel
+
+	require.False(t, resp.State.Get(t.Context(), &saved).HasError())
+	require.Equal(t, "v1.32.3", saved.KubernetesVersion.ValueString())
+	require.Equal(t, "1.32.3", saved.LastUpgradeVersion.ValueString())
+}
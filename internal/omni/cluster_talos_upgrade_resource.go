@@ -0,0 +1,422 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	cosi_res "github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var (
+	_ resource.Resource                = &clusterTalosUpgradeResource{}
+	_ resource.ResourceWithModifyPlan  = &clusterTalosUpgradeResource{}
+	_ resource.ResourceWithImportState = &clusterTalosUpgradeResource{}
+)
+
+func NewClusterTalosUpgradeResource() resource.Resource {
+	return &clusterTalosUpgradeResource{}
+}
+
+type clusterTalosUpgradeResource struct {
+	provider *omniProvider
+}
+
+type clusterTalosUpgradeResourceModel struct {
+	ID                 types.String   `tfsdk:"id"`
+	Cluster            types.String   `tfsdk:"cluster"`
+	TalosVersion       types.String   `tfsdk:"talos_version"`
+	Extensions         []types.String `tfsdk:"extensions"`
+	Timeout            types.String   `tfsdk:"timeout"`
+	RevertOnFailure    types.Bool     `tfsdk:"revert_on_failure"`
+	LastUpgradeVersion types.String   `tfsdk:"last_upgrade_version"`
+}
+
+func (r *clusterTalosUpgradeResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cluster_talos_upgrade"
+}
+
+func (r *clusterTalosUpgradeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Upgrades the Talos version and the system extensions of a cluster and follows the rolling upgrade until it completes or fails. " +
+			"Destroying the resource leaves the cluster version and extensions as they are. " +
+			"Pausing or holding an upgrade is not supported: a running upgrade can only be cancelled with `revert_on_failure`, " +
+			"and machines locked in Omni are skipped by the upgrade, so it times out.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cluster": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster to upgrade",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"talos_version": schema.StringAttribute{
+				MarkdownDescription: "The Talos version of the cluster, e.g. '1.9.5'",
+				Required:            true,
+			},
+			"extensions": schema.ListAttribute{
+				MarkdownDescription: "The system extensions of all the machines of the cluster. " +
+					"They are updated together with the Talos version, so a single rolling upgrade installs both. If not set, the extensions are not managed",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"timeout": schema.StringAttribute{
+				MarkdownDescription: "How long to wait for the upgrade to complete as a Go duration. Defaults to '" + defaultUpgradeTimeout + "'",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(defaultUpgradeTimeout),
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"revert_on_failure": schema.BoolAttribute{
+				MarkdownDescription: "Cancel the upgrade by reverting the cluster to the last successfully upgraded Talos version when the upgrade fails or times out",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"last_upgrade_version": schema.StringAttribute{
+				MarkdownDescription: "The version of the last successful upgrade reported by Omni",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (r *clusterTalosUpgradeResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+// ModifyPlan checks that the Talos version is known to Omni and that the extensions are available for it.
+func (r *clusterTalosUpgradeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || !req.Plan.Raw.IsFullyKnown() || r.provider == nil {
+		return
+	}
+
+	var plan clusterTalosUpgradeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	st := r.provider.state
+	version := strings.TrimPrefix(plan.TalosVersion.ValueString(), "v")
+
+	if _, err := safe.StateGetByID[*omni.TalosVersion](ctx, st, version); err != nil {
+		if state.IsNotFoundError(err) {
+			resp.Diagnostics.AddAttributeError(path.Root("talos_version"), errValidationFailed, fmt.Sprintf("Talos version '%s' is not available in Omni", version))
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read Talos version '%s': %v", version, err))
+		return
+	}
+
+	if err := validateExtensions(ctx, st, version, stringValues(plan.Extensions)); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("extensions"), errValidationFailed, err.Error())
+	}
+}
+
+func (r *clusterTalosUpgradeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan clusterTalosUpgradeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.upgrade(ctx, &plan); err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *clusterTalosUpgradeResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	st := r.provider.state
+
+	var tfState clusterTalosUpgradeResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cluster, err := safe.StateGetByID[*omni.Cluster](ctx, st, tfState.ID.ValueString())
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read cluster '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	tfState.Cluster = tfState.ID
+	if strings.TrimPrefix(tfState.TalosVersion.ValueString(), "v") != cluster.TypedSpec().Value.TalosVersion {
+		tfState.TalosVersion = types.StringValue(cluster.TypedSpec().Value.TalosVersion)
+	}
+
+	if tfState.Timeout.IsNull() {
+		tfState.Timeout = types.StringValue(defaultUpgradeTimeout)
+	}
+
+	if tfState.RevertOnFailure.IsNull() {
+		tfState.RevertOnFailure = types.BoolValue(false)
+	}
+
+	if tfState.Extensions != nil {
+		extensions, err := safe.StateGetByID[*omni.ExtensionsConfiguration](ctx, st, clusterExtensionsConfigurationID(tfState.ID.ValueString()))
+		if err != nil && !state.IsNotFoundError(err) {
+			resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read extensions of cluster '%s': %v", tfState.ID.ValueString(), err))
+			return
+		}
+
+		// a configured empty list stays empty instead of becoming null
+		tfState.Extensions = []types.String{}
+		if extensions != nil && len(extensions.TypedSpec().Value.Extensions) > 0 {
+			tfState.Extensions = stringList(extensions.TypedSpec().Value.Extensions)
+		}
+	}
+
+	upgradeStatus, err := safe.StateGetByID[*omni.TalosUpgradeStatus](ctx, st, tfState.ID.ValueString())
+	if err != nil && !state.IsNotFoundError(err) {
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read upgrade status of cluster '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	tfState.LastUpgradeVersion = types.StringValue("")
+	if upgradeStatus != nil {
+		tfState.LastUpgradeVersion = types.StringValue(upgradeStatus.TypedSpec().Value.LastUpgradeVersion)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, tfState)...)
+}
+
+func (r *clusterTalosUpgradeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan clusterTalosUpgradeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.upgrade(ctx, &plan); err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Delete only removes the resource from the state, removing the extensions or downgrading Talos would trigger another upgrade.
+func (r *clusterTalosUpgradeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+func (r *clusterTalosUpgradeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// upgrade updates the cluster extensions and Talos version and waits for the rolling upgrade to finish.
+// The configured version is kept in the plan, the version without the 'v' prefix is only used for Omni.
+func (r *clusterTalosUpgradeResource) upgrade(ctx context.Context, plan *clusterTalosUpgradeResourceModel) error {
+	st := r.provider.state
+	clusterName := plan.Cluster.ValueString()
+	version := strings.TrimPrefix(plan.TalosVersion.ValueString(), "v")
+
+	timeout, err := time.ParseDuration(plan.Timeout.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid timeout '%s': %v", plan.Timeout.ValueString(), err)
+	}
+
+	cluster, err := safe.StateGetByID[*omni.Cluster](ctx, st, clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to read cluster '%s': %v", clusterName, err)
+	}
+
+	extensionsChanged := false
+
+	if plan.Extensions != nil {
+		if extensionsChanged, err = r.setExtensions(ctx, clusterName, stringValues(plan.Extensions)); err != nil {
+			return err
+		}
+	}
+
+	previousVersion := cluster.TypedSpec().Value.TalosVersion
+	if previousVersion != version {
+		if err := setClusterTalosVersion(ctx, st, cluster.Metadata(), version); err != nil {
+			return err
+		}
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var last *specs.TalosUpgradeStatusSpec
+
+	// When only the extensions change, the status is Done with the current version until Omni picks up the change,
+	// so Done only counts once the status has left it.
+	pending := extensionsChanged && previousVersion == version
+
+	_, err = st.WatchFor(watchCtx, omni.NewTalosUpgradeStatus(resources.DefaultNamespace, clusterName).Metadata(),
+		state.WithCondition(func(res cosi_res.Resource) (bool, error) {
+			upgradeStatus, ok := res.(*omni.TalosUpgradeStatus)
+			if !ok {
+				return false, nil
+			}
+
+			last = upgradeStatus.TypedSpec().Value
+
+			tflog.Info(ctx, "talos upgrade progress", map[string]any{
+				"cluster": clusterName,
+				"phase":   last.Phase.String(),
+				"step":    last.Step,
+				"status":  last.Status,
+			})
+
+			if last.Phase != specs.TalosUpgradeStatusSpec_Done {
+				pending = false
+			}
+
+			return talosUpgradeFinished(last, version, pending)
+		}),
+	)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && last != nil {
+			err = fmt.Errorf("timed out waiting for the upgrade of cluster '%s' to Talos %s, last phase %s, step '%s': %s",
+				clusterName, version, last.Phase, last.Step, last.Status)
+		} else {
+			err = fmt.Errorf("upgrade of cluster '%s' to Talos %s failed: %v", clusterName, version, err)
+		}
+
+		if plan.RevertOnFailure.ValueBool() && previousVersion != version {
+			revertVersion := previousVersion
+			if last != nil && last.LastUpgradeVersion != "" {
+				revertVersion = last.LastUpgradeVersion
+			}
+
+			if revertErr := setClusterTalosVersion(ctx, st, cluster.Metadata(), revertVersion); revertErr != nil {
+				return fmt.Errorf("%w, reverting to Talos %s failed: %v", err, revertVersion, revertErr)
+			}
+
+			return fmt.Errorf("%w, the cluster is reverting to Talos %s", err, revertVersion)
+		}
+
+		return err
+	}
+
+	plan.ID = plan.Cluster
+	plan.LastUpgradeVersion = types.StringValue(last.LastUpgradeVersion)
+
+	return nil
+}
+
+// setExtensions writes the cluster wide extensions, it reports whether they changed.
+func (r *clusterTalosUpgradeResource) setExtensions(ctx context.Context, clusterName string, extensions []string) (bool, error) {
+	st := r.provider.state
+
+	configuration := omni.NewExtensionsConfiguration(resources.DefaultNamespace, clusterExtensionsConfigurationID(clusterName))
+
+	existing, err := safe.StateGet[*omni.ExtensionsConfiguration](ctx, st, configuration.Metadata())
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return false, fmt.Errorf("failed to read extensions of cluster '%s': %v", clusterName, err)
+		}
+
+		configuration.Metadata().Labels().Set(omni.LabelCluster, clusterName)
+		configuration.TypedSpec().Value.Extensions = extensions
+
+		if err := st.Create(ctx, configuration); err != nil {
+			return false, fmt.Errorf("failed to create extensions of cluster '%s': %v", clusterName, err)
+		}
+
+		return len(extensions) > 0, nil
+	}
+
+	if slices.Equal(existing.TypedSpec().Value.Extensions, extensions) {
+		return false, nil
+	}
+
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, configuration.Metadata(), func(res *omni.ExtensionsConfiguration) error {
+		res.TypedSpec().Value.Extensions = extensions
+
+		return nil
+	}); err != nil {
+		return false, fmt.Errorf("failed to update extensions of cluster '%s': %v", clusterName, err)
+	}
+
+	return true, nil
+}
+
+// Helper functions
+func setClusterTalosVersion(ctx context.Context, st state.State, md cosi_res.Pointer, version string) error {
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, md, func(res *omni.Cluster) error {
+		res.TypedSpec().Value.TalosVersion = version
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to set Talos version of cluster '%s': %v", md.ID(), err)
+	}
+
+	return nil
+}
+
+// clusterExtensionsConfigurationID returns the ID cluster templates use for the cluster wide extensions.
+func clusterExtensionsConfigurationID(clusterName string) string {
+	return "schematic-" + clusterName
+}
+
+// talosUpgradeFinished reports whether the upgrade to the version is done, pending means the Done status predates the upgrade.
+func talosUpgradeFinished(status *specs.TalosUpgradeStatusSpec, version string, pending bool) (bool, error) {
+	switch status.Phase {
+	case specs.TalosUpgradeStatusSpec_Done:
+		return !pending && status.LastUpgradeVersion == version, nil
+	case specs.TalosUpgradeStatusSpec_Failed, specs.TalosUpgradeStatusSpec_Reverting:
+		if status.CurrentUpgradeVersion != "" && status.CurrentUpgradeVersion != version {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("phase %s at step '%s': %s", status.Phase, status.Step, status.Error)
+	default:
+		return false, nil
+	}
+}
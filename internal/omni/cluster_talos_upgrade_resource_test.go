@@ -0,0 +1,172 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterTalosUpgradeResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_cluster_talos_upgrade" "test" {
+  cluster       = "talos-default"
+  talos_version = "1.9.5"
+  extensions    = ["siderolabs/qemu-guest-agent"]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_cluster_talos_upgrade.test", "id", "talos-default"),
+					resource.TestCheckResourceAttr("omni_cluster_talos_upgrade.test", "last_upgrade_version", "1.9.5"),
+					resource.TestCheckResourceAttr("omni_cluster_talos_upgrade.test", "extensions.0", "siderolabs/qemu-guest-agent"),
+				),
+			},
+		},
+	})
+}
+
+func TestTalosUpgradeFinished(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		status   *specs.TalosUpgradeStatusSpec
+		finished bool
+		pending  bool
+		err      string
+	}{
+		{
+			name:   "installing extensions",
+			status: &specs.TalosUpgradeStatusSpec{Phase: specs.TalosUpgradeStatusSpec_InstallingExtensions, LastUpgradeVersion: "1.9.5"},
+		},
+		{
+			name:   "done with previous version",
+			status: &specs.TalosUpgradeStatusSpec{Phase: specs.TalosUpgradeStatusSpec_Done, LastUpgradeVersion: "1.9.4"},
+		},
+		{
+			name:     "done",
+			status:   &specs.TalosUpgradeStatusSpec{Phase: specs.TalosUpgradeStatusSpec_Done, LastUpgradeVersion: "1.9.5"},
+			finished: true,
+		},
+		{
+			name:    "done before the extensions update",
+			status:  &specs.TalosUpgradeStatusSpec{Phase: specs.TalosUpgradeStatusSpec_Done, LastUpgradeVersion: "1.9.5"},
+			pending: true,
+		},
+		{
+			name:   "reverting",
+			status: &specs.TalosUpgradeStatusSpec{Phase: specs.TalosUpgradeStatusSpec_Reverting, CurrentUpgradeVersion: "1.9.5", Step: "upgrading machine-1", Error: "boom"},
+			err:    "phase Reverting at step 'upgrading machine-1': boom",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			finished, err := talosUpgradeFinished(tc.status, "1.9.5", tc.pending)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.finished, finished)
+		})
+	}
+}
+
+func newTalosUpgradeTestState(t *testing.T) state.State { //nolint:ireturn
+	t.Helper()
+
+	st := newTestState()
+
+	cluster := omni.NewCluster(resources.DefaultNamespace, "talos-default")
+	cluster.TypedSpec().Value.TalosVersion = "1.9.5"
+	require.NoError(t, st.Create(t.Context(), cluster))
+
+	upgradeStatus := omni.NewTalosUpgradeStatus(resources.DefaultNamespace, "talos-default")
+	upgradeStatus.TypedSpec().Value.Phase = specs.TalosUpgradeStatusSpec_Done
+	upgradeStatus.TypedSpec().Value.LastUpgradeVersion = "1.9.5"
+	require.NoError(t, st.Create(t.Context(), upgradeStatus))
+
+	return st
+}
+
+func TestClusterTalosUpgradeWaitsForExtensions(t *testing.T) {
+	st := newTalosUpgradeTestState(t)
+	statusMD := omni.NewTalosUpgradeStatus(resources.DefaultNamespace, "talos-default").Metadata()
+
+	// Omni installs the extensions once their configuration is written
+	go func() {
+		if _, err := st.WatchFor(t.Context(), omni.NewExtensionsConfiguration(resources.DefaultNamespace, clusterExtensionsConfigurationID("talos-default")).Metadata(),
+			state.WithEventTypes(state.Created, state.Updated)); err != nil {
+			return
+		}
+
+		for _, phase := range []specs.TalosUpgradeStatusSpec_Phase{specs.TalosUpgradeStatusSpec_InstallingExtensions, specs.TalosUpgradeStatusSpec_Done} {
+			if _, err := safe.StateUpdateWithConflicts(t.Context(), st, statusMD, func(res *omni.TalosUpgradeStatus) error {
+				res.TypedSpec().Value.Phase = phase
+
+				return nil
+			}); err != nil {
+				return
+			}
+		}
+	}()
+
+	r := &clusterTalosUpgradeResource{provider: &omniProvider{state: st}}
+
+	resp := createWithPlan(t, r, clusterTalosUpgradeResourceModel{
+		ID:                 types.StringUnknown(),
+		Cluster:            types.StringValue("talos-default"),
+		TalosVersion:       types.StringValue("v1.9.5"),
+		Extensions:         []types.String{types.StringValue("siderolabs/iscsi-tools")},
+		Timeout:            types.StringValue("10s"),
+		RevertOnFailure:    types.BoolValue(false),
+		LastUpgradeVersion: types.StringUnknown(),
+	})
+	require.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+	upgradeStatus, err := safe.StateGet[*omni.TalosUpgradeStatus](t.Context(), st, statusMD)
+	require.NoError(t, err)
+	require.Equal(t, specs.TalosUpgradeStatusSpec_Done, upgradeStatus.TypedSpec().Value.Phase)
+	require.Greater(t, upgradeStatus.Metadata().Version().Value(), uint64(2), "the upgrade finished before the extensions were installed")
+
+	var saved clusterTalosUpgradeResourceModel
+
+	require.False(t, resp.State.Get(t.Context(), &saved).HasError())
+	require.Equal(t, "v1.9.5", saved.TalosVersion.ValueString())
+}
+
+func TestClusterTalosUpgradeReadKeepsEmptyExtensions(t *testing.T) {
+	st := newTalosUpgradeTestState(t)
+
+	configuration := omni.NewExtensionsConfiguration(resources.DefaultNamespace, clusterExtensionsConfigurationID("talos-default"))
+	require.NoError(t, st.Create(t.Context(), configuration))
+
+	r := &clusterTalosUpgradeResource{provider: &omniProvider{state: st}}
+
+	resp := readWithState(t, r, clusterTalosUpgradeResourceModel{
+		ID:                 types.StringValue("talos-default"),
+		Cluster:            types.StringValue("talos-default"),
+		TalosVersion:       types.StringValue("v1.9.5"),
+		Extensions:         []types.String{},
+		Timeout:            types.StringValue(defaultUpgradeTimeout),
+		RevertOnFailure:    types.BoolValue(false),
+		LastUpgradeVersion: types.StringValue("1.9.5"),
+	})
+	require.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+	var saved clusterTalosUpgradeResourceModel
+
+	require.False(t, resp.State.Get(t.Context(), &saved).HasError())
+	require.NotNil(t, saved.Extensions)
+	require.Empty(t, saved.Extensions)
+	require.Equal(t, "v1.9.5", saved.TalosVersion.ValueString())
+}
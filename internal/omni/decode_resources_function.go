@@ -0,0 +1,179 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	cosi_res "github.com/cosi-project/runtime/pkg/resource"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+var _ function.Function = &decodeResourcesFunction{}
+
+func NewDecodeResourcesFunction() function.Function {
+	return &decodeResourcesFunction{}
+}
+
+type decodeResourcesFunction struct{}
+
+func (f *decodeResourcesFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "decode_resources"
+}
+
+func (f *decodeResourcesFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Decode the Omni resources of a YAML document",
+		MarkdownDescription: "Decodes the resources of a multi-document YAML, like the `yaml` of `omni_apply_yaml`, and returns a list " +
+			"of objects with the `metadata` (`namespace`, `type`, `id`, `labels` and `annotations`) and the `spec` of every resource. " +
+			"The resource types must be known to Omni, the spec only keeps the fields of the resource type.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "yaml",
+				MarkdownDescription: "The YAML documents of the resources.",
+			},
+		},
+		Return: function.DynamicReturn{},
+	}
+}
+
+func (f *decodeResourcesFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var input string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &input))
+	if resp.Error != nil {
+		return
+	}
+
+	resources, diags := (&applyYamlResource{}).decodeYAMLResources(ctx, input)
+	if diags.HasError() {
+		resp.Error = function.NewArgumentFuncError(0, diags.Errors()[0].Detail())
+		return
+	}
+
+	elems := make([]attr.Value, 0, len(resources))
+
+	for _, res := range resources {
+		value, err := resourceValue(res)
+		if err != nil {
+			resp.Error = function.NewFuncError(err.Error())
+			return
+		}
+
+		elems = append(elems, value)
+	}
+
+	result, diags := types.TupleValue(attrTypes(elems), elems)
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, types.DynamicValue(result))
+}
+
+// resourceValue converts the resource to an object with its metadata and spec.
+func resourceValue(res cosi_res.Resource) (attr.Value, error) {
+	specYAML, err := yaml.Marshal(res.Spec())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the spec of resource '%s' of type '%s': %w", res.Metadata().ID(), res.Metadata().Type(), err)
+	}
+
+	var spec any
+
+	if err = yaml.Unmarshal(specYAML, &spec); err != nil {
+		return nil, fmt.Errorf("failed to decode the spec of resource '%s' of type '%s': %w", res.Metadata().ID(), res.Metadata().Type(), err)
+	}
+
+	md := res.Metadata()
+
+	return yamlValue(map[string]any{
+		"metadata": map[string]any{
+			"namespace":   md.Namespace(),
+			"type":        md.Type(),
+			"id":          md.ID(),
+			"labels":      stringMap(md.Labels().Raw()),
+			"annotations": stringMap(md.Annotations().Raw()),
+		},
+		"spec": spec,
+	}), nil
+}
+
+func stringMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+
+	for k, v := range m {
+		out[k] = v
+	}
+
+	return out
+}
+
+// yamlValue converts a decoded YAML value to a Terraform value, the lists become tuples and the maps objects,
+// so the values of the spec can have different types.
+func yamlValue(v any) attr.Value {
+	switch v := v.(type) {
+	case nil:
+		return types.StringNull()
+	case string:
+		return types.StringValue(v)
+	case bool:
+		return types.BoolValue(v)
+	case int:
+		return types.NumberValue(new(big.Float).SetInt64(int64(v)))
+	case int64:
+		return types.NumberValue(new(big.Float).SetInt64(v))
+	case uint64:
+		return types.NumberValue(new(big.Float).SetUint64(v))
+	case float64:
+		return types.NumberValue(big.NewFloat(v))
+	case time.Time:
+		return types.StringValue(v.Format(time.RFC3339))
+	case []any:
+		elems := make([]attr.Value, 0, len(v))
+
+		for _, elem := range v {
+			elems = append(elems, yamlValue(elem))
+		}
+
+		return types.TupleValueMust(attrTypes(elems), elems)
+	case map[string]any:
+		attrs := make(map[string]attr.Value, len(v))
+		objectTypes := make(map[string]attr.Type, len(v))
+
+		for key, elem := range v {
+			attrs[key] = yamlValue(elem)
+			objectTypes[key] = attrs[key].Type(context.Background())
+		}
+
+		return types.ObjectValueMust(objectTypes, attrs)
+	case map[any]any:
+		m := make(map[string]any, len(v))
+
+		for key, elem := range v {
+			m[fmt.Sprint(key)] = elem
+		}
+
+		return yamlValue(m)
+	default:
+		return types.StringValue(fmt.Sprint(v))
+	}
+}
+
+func attrTypes(values []attr.Value) []attr.Type {
+	out := make([]attr.Type, 0, len(values))
+
+	for _, v := range values {
+		out = append(out, v.Type(context.Background()))
+	}
+
+	return out
+}
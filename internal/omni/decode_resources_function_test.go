@@ -0,0 +1,78 @@
+package omni
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+const testResourcesYAML = `metadata:
+    namespace: default
+    type: MachineClasses.omni.sidero.dev
+    id: workers
+    labels:
+        env: prod
+spec:
+    matchlabels:
+        - omni.sidero.dev/arch = amd64
+---
+metadata:
+    namespace: default
+    type: MachineSets.omni.sidero.dev
+    id: talos-workers
+spec:
+    updatestrategy: 1
+    machineallocation:
+        name: workers
+        machinecount: 2
+`
+
+func runDecodeResources(t *testing.T, input string) (types.Dynamic, *function.FuncError) {
+	t.Helper()
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.DynamicUnknown())}
+
+	(&decodeResourcesFunction{}).Run(t.Context(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(input)}),
+	}, resp)
+
+	result, _ := resp.Result.Value().(types.Dynamic)
+
+	return result, resp.Error
+}
+
+func TestDecodeResourcesFunction(t *testing.T) {
+	result, funcErr := runDecodeResources(t, testResourcesYAML)
+	require.Nil(t, funcErr)
+
+	resources, ok := result.UnderlyingValue().(types.Tuple)
+	require.True(t, ok)
+	require.Len(t, resources.Elements(), 2)
+
+	class := resources.Elements()[0].(types.Object).Attributes()            //nolint:forcetypeassert
+	metadata := class["metadata"].(types.Object).Attributes()               //nolint:forcetypeassert
+	labels := metadata["labels"].(types.Object).Attributes()                //nolint:forcetypeassert
+	matchLabels := class["spec"].(types.Object).Attributes()["matchlabels"] //nolint:forcetypeassert
+	require.Equal(t, types.StringValue("MachineClasses.omni.sidero.dev"), metadata["type"])
+	require.Equal(t, types.StringValue("workers"), metadata["id"])
+	require.Equal(t, types.StringValue("prod"), labels["env"])
+	require.Equal(t, []attr.Value{types.StringValue("omni.sidero.dev/arch = amd64")}, matchLabels.(types.Tuple).Elements()) //nolint:forcetypeassert
+
+	machineSet := resources.Elements()[1].(types.Object).Attributes()                                //nolint:forcetypeassert
+	allocation := machineSet["spec"].(types.Object).Attributes()["machineallocation"].(types.Object) //nolint:forcetypeassert
+	require.Equal(t, types.NumberValue(new(big.Float).SetInt64(2)), allocation.Attributes()["machinecount"])
+}
+
+func TestDecodeResourcesFunctionErrors(t *testing.T) {
+	_, funcErr := runDecodeResources(t, "metadata: [")
+	require.NotNil(t, funcErr)
+	require.Contains(t, funcErr.Error(), "Failed to decode YAML")
+
+	_, funcErr = runDecodeResources(t, "metadata:\n  type: Unknown.omni.sidero.dev\n  id: a\nspec: {}\n")
+	require.NotNil(t, funcErr)
+	require.Contains(t, funcErr.Error(), "no resource is registered")
+}
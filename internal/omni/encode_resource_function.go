@@ -0,0 +1,245 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	cosi_res "github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/resource/protobuf"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"gopkg.in/yaml.v3"
+)
+
+// canonicalMetadataKeys are the metadata fields set by the user, the others are managed by Omni.
+var canonicalMetadataKeys = []string{"namespace", "type", "id", "labels", "annotations"}
+
+var _ function.Function = &encodeResourceFunction{}
+
+func NewEncodeResourceFunction() function.Function {
+	return &encodeResourceFunction{}
+}
+
+type encodeResourceFunction struct{}
+
+func (f *encodeResourceFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "encode_resource"
+}
+
+func (f *encodeResourceFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Encode an Omni resource as YAML",
+		MarkdownDescription: "Encodes an object with the `metadata` and the `spec` of a resource, like the ones returned by `decode_resources`, " +
+			"as the YAML of the resource in the format Omni uses. The spec is checked against the resource type, the fields are ordered " +
+			"like Omni orders them and the metadata managed by Omni, like the version, is left out.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:                "resource",
+				MarkdownDescription: "The resource with the `metadata` (`namespace`, `type`, `id` and optionally `labels` and `annotations`) and the `spec`.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *encodeResourceFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var input types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &input))
+	if resp.Error != nil {
+		return
+	}
+
+	if input.IsNull() || input.IsUnderlyingValueNull() {
+		resp.Error = function.NewArgumentFuncError(0, "The resource must not be null")
+		return
+	}
+
+	tfValue, err := input.UnderlyingValue().ToTerraformValue(ctx)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	value, err := terraformValueToYAML(tfValue)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid resource: %s", err))
+		return
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Failed to encode the resource: %s", err))
+		return
+	}
+
+	var yamlResource protobuf.YAMLResource
+
+	if err = yaml.Unmarshal(data, &yamlResource); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Failed to decode the resource: %s", err))
+		return
+	}
+
+	out, err := canonicalResourceYAML(yamlResource.Resource())
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, out)
+}
+
+// canonicalResourceYAML encodes the resource like Omni without the metadata managed by Omni, e.g. the version and the timestamps.
+func canonicalResourceYAML(r cosi_res.Resource) (string, error) {
+	out, err := cosi_res.MarshalYAML(r)
+	if err != nil {
+		return "", err
+	}
+
+	var node yaml.Node
+
+	if err = node.Encode(out); err != nil {
+		return "", fmt.Errorf("failed to encode resource '%s' of type '%s': %w", r.Metadata().ID(), r.Metadata().Type(), err)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "metadata" {
+			continue
+		}
+
+		md := node.Content[i+1]
+
+		var kept []*yaml.Node
+
+		for j := 0; j+1 < len(md.Content); j += 2 {
+			if slices.Contains(canonicalMetadataKeys, md.Content[j].Value) {
+				kept = append(kept, md.Content[j], md.Content[j+1])
+			}
+		}
+
+		md.Content = kept
+	}
+
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode resource '%s' of type '%s': %w", r.Metadata().ID(), r.Metadata().Type(), err)
+	}
+
+	return string(data), nil
+}
+
+// terraformValueToYAML converts a Terraform value to a value which can be encoded as YAML, the null attributes are left out.
+func terraformValueToYAML(v tftypes.Value) (any, error) {
+	if !v.IsKnown() {
+		return nil, errors.New("the value is not known yet")
+	}
+
+	if v.IsNull() {
+		return nil, nil //nolint:nilnil
+	}
+
+	switch typ := v.Type(); {
+	case typ.Is(tftypes.String):
+		var s string
+
+		return s, v.As(&s)
+	case typ.Is(tftypes.Bool):
+		var b bool
+
+		return b, v.As(&b)
+	case typ.Is(tftypes.Number):
+		var n big.Float
+
+		if err := v.As(&n); err != nil {
+			return nil, err
+		}
+
+		if i, accuracy := n.Int64(); n.IsInt() && accuracy == big.Exact {
+			return i, nil
+		}
+
+		f, _ := n.Float64()
+
+		return f, nil
+	case typ.Is(tftypes.List{}), typ.Is(tftypes.Set{}), typ.Is(tftypes.Tuple{}):
+		var elems []tftypes.Value
+
+		if err := v.As(&elems); err != nil {
+			return nil, err
+		}
+
+		out := make([]any, 0, len(elems))
+
+		for _, elem := range elems {
+			value, err := terraformValueToYAML(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, value)
+		}
+
+		return out, nil
+	case typ.Is(tftypes.Map{}), typ.Is(tftypes.Object{}):
+		var elems map[string]tftypes.Value
+
+		if err := v.As(&elems); err != nil {
+			return nil, err
+		}
+
+		out := make(map[string]any, len(elems))
+
+		for key, elem := range elems {
+			if elem.IsNull() {
+				continue
+			}
+
+			value, err := terraformValueToYAML(elem)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+
+			out[key] = value
+		}
+
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %s", typ)
+	}
+}
+
+// decodeDynamicValue decodes a Terraform value into target like YAML, the attributes which are not fields of target are rejected.
+func decodeDynamicValue(ctx context.Context, value types.Dynamic, target any) error {
+	if value.IsNull() || value.IsUnderlyingValueNull() {
+		return errors.New("the value must not be null")
+	}
+
+	tfValue, err := value.UnderlyingValue().ToTerraformValue(ctx)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := terraformValueToYAML(tfValue)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(decoded)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	return decoder.Decode(target)
+}
@@ -0,0 +1,91 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func runEncodeResource(t *testing.T, input attr.Value) (string, *function.FuncError) {
+	t.Helper()
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+
+	(&encodeResourceFunction{}).Run(t.Context(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.DynamicValue(input)}),
+	}, resp)
+
+	result, _ := resp.Result.Value().(types.String)
+
+	return result.ValueString(), resp.Error
+}
+
+func TestEncodeResourceFunction(t *testing.T) {
+	input := yamlValue(map[string]any{
+		"metadata": map[string]any{
+			"namespace": "default",
+			"type":      "MachineClasses.omni.sidero.dev",
+			"id":        "workers",
+			"labels":    map[string]any{"env": "prod"},
+		},
+		"spec": map[string]any{
+			"matchlabels":   []any{"omni.sidero.dev/arch = amd64"},
+			"autoprovision": nil,
+		},
+	})
+
+	out, funcErr := runEncodeResource(t, input)
+	require.Nil(t, funcErr)
+	require.Equal(t, `metadata:
+    namespace: default
+    type: MachineClasses.omni.sidero.dev
+    id: workers
+    labels:
+        env: prod
+spec:
+    matchlabels:
+        - omni.sidero.dev/arch = amd64
+    autoprovision: null
+`, out)
+}
+
+func TestEncodeResourceFunctionRoundTrip(t *testing.T) {
+	result, funcErr := runDecodeResources(t, testResourcesYAML)
+	require.Nil(t, funcErr)
+
+	expected, diags := (&applyYamlResource{}).decodeYAMLResources(t.Context(), testResourcesYAML)
+	require.False(t, diags.HasError())
+
+	elems := result.UnderlyingValue().(types.Tuple).Elements() //nolint:forcetypeassert
+	require.Len(t, elems, len(expected))
+
+	for i, elem := range elems {
+		out, funcErr := runEncodeResource(t, elem)
+		require.Nil(t, funcErr)
+
+		canonical, err := canonicalResourceYAML(expected[i])
+		require.NoError(t, err)
+		require.Equal(t, canonical, out)
+	}
+}
+
+func TestEncodeResourceFunctionErrors(t *testing.T) {
+	_, funcErr := runEncodeResource(t, types.StringValue("metadata"))
+	require.NotNil(t, funcErr)
+
+	_, funcErr = runEncodeResource(t, yamlValue(map[string]any{
+		"metadata": map[string]any{"namespace": "default", "type": "MachineClasses.omni.sidero.dev", "id": "workers"},
+	}))
+	require.NotNil(t, funcErr)
+	require.Contains(t, funcErr.Error(), "Failed to decode the resource")
+
+	_, funcErr = runEncodeResource(t, types.ObjectValueMust(
+		map[string]attr.Type{"metadata": types.StringType, "spec": types.StringType},
+		map[string]attr.Value{"metadata": types.StringUnknown(), "spec": types.StringValue("")},
+	))
+	require.NotNil(t, funcErr)
+	require.Contains(t, funcErr.Error(), "not known yet")
+}
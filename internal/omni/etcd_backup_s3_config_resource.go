@@ -0,0 +1,229 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var (
+	_ resource.Resource                = &etcdBackupS3ConfigResource{}
+	_ resource.ResourceWithImportState = &etcdBackupS3ConfigResource{}
+)
+
+func NewEtcdBackupS3ConfigResource() resource.Resource {
+	return &etcdBackupS3ConfigResource{}
+}
+
+type etcdBackupS3ConfigResource struct {
+	provider *omniProvider
+}
+
+type etcdBackupS3ConfigResourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	Bucket          types.String `tfsdk:"bucket"`
+	Region          types.String `tfsdk:"region"`
+	Endpoint        types.String `tfsdk:"endpoint"`
+	AccessKeyID     types.String `tfsdk:"access_key_id"`
+	SecretAccessKey types.String `tfsdk:"secret_access_key"`
+	SessionToken    types.String `tfsdk:"session_token"`
+}
+
+func (r *etcdBackupS3ConfigResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_etcd_backup_s3_config"
+}
+
+func (r *etcdBackupS3ConfigResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages the S3 storage of the etcd backups. There is a single S3 configuration per Omni instance, " +
+			"destroying the resource removes it and disables storing backups in S3.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the S3 configuration, always '" + omni.EtcdBackupS3ConfID + "'",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"bucket": schema.StringAttribute{
+				MarkdownDescription: "The S3 bucket",
+				Required:            true,
+			},
+			"region": schema.StringAttribute{
+				MarkdownDescription: "The S3 region",
+				Optional:            true,
+			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "The S3 endpoint, for S3 compatible storage",
+				Optional:            true,
+			},
+			"access_key_id": schema.StringAttribute{
+				MarkdownDescription: "The S3 access key ID",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"secret_access_key": schema.StringAttribute{
+				MarkdownDescription: "The S3 secret access key",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"session_token": schema.StringAttribute{
+				MarkdownDescription: "The S3 session token",
+				Optional:            true,
+				Sensitive:           true,
+			},
+		},
+	}
+}
+
+func (r *etcdBackupS3ConfigResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *etcdBackupS3ConfigResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan etcdBackupS3ConfigResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(ctx, plan); err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	plan.ID = types.StringValue(omni.EtcdBackupS3ConfID)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *etcdBackupS3ConfigResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	st := r.provider.state
+
+	var tfState etcdBackupS3ConfigResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	conf, err := safe.StateGetByID[*omni.EtcdBackupS3Conf](ctx, st, omni.EtcdBackupS3ConfID)
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read the etcd backup S3 configuration: %v", err))
+		return
+	}
+
+	spec := conf.TypedSpec().Value
+
+	tfState.ID = types.StringValue(omni.EtcdBackupS3ConfID)
+	tfState.Bucket = types.StringValue(spec.Bucket)
+	tfState.Region = optionalStringValue(spec.Region)
+	tfState.Endpoint = optionalStringValue(spec.Endpoint)
+	tfState.AccessKeyID = optionalStringValue(spec.AccessKeyId)
+	tfState.SessionToken = optionalStringValue(spec.SessionToken)
+
+	// Omni may not return the secret, keep the one from the state then
+	if spec.SecretAccessKey != "" || tfState.SecretAccessKey.IsNull() {
+		tfState.SecretAccessKey = optionalStringValue(spec.SecretAccessKey)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, tfState)...)
+}
+
+func (r *etcdBackupS3ConfigResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan etcdBackupS3ConfigResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(ctx, plan); err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, err.Error())
+		return
+	}
+
+	plan.ID = types.StringValue(omni.EtcdBackupS3ConfID)
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *etcdBackupS3ConfigResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	st := r.provider.state
+
+	if err := st.Destroy(ctx, omni.NewEtcdBackupS3Conf().Metadata()); err != nil && !state.IsNotFoundError(err) {
+		resp.Diagnostics.AddError(errDeleteFailed, fmt.Sprintf("Failed to delete the etcd backup S3 configuration: %v", err))
+	}
+}
+
+func (r *etcdBackupS3ConfigResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// apply creates the S3 configuration or updates the existing one.
+func (r *etcdBackupS3ConfigResource) apply(ctx context.Context, plan etcdBackupS3ConfigResourceModel) error {
+	st := r.provider.state
+
+	setSpec := func(spec *specs.EtcdBackupS3ConfSpec) {
+		spec.Bucket = plan.Bucket.ValueString()
+		spec.Region = plan.Region.ValueString()
+		spec.Endpoint = plan.Endpoint.ValueString()
+		spec.AccessKeyId = plan.AccessKeyID.ValueString()
+		spec.SecretAccessKey = plan.SecretAccessKey.ValueString()
+		spec.SessionToken = plan.SessionToken.ValueString()
+	}
+
+	conf := omni.NewEtcdBackupS3Conf()
+
+	_, err := st.Get(ctx, conf.Metadata())
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return fmt.Errorf("failed to read the etcd backup S3 configuration: %v", err)
+		}
+
+		setSpec(conf.TypedSpec().Value)
+
+		if err := st.Create(ctx, conf); err != nil {
+			return fmt.Errorf("failed to create the etcd backup S3 configuration: %v", err)
+		}
+
+		return nil
+	}
+
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, conf.Metadata(), func(res *omni.EtcdBackupS3Conf) error {
+		setSpec(res.TypedSpec().Value)
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to update the etcd backup S3 configuration: %v", err)
+	}
+
+	return nil
+}
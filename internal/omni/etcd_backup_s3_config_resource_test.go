@@ -0,0 +1,66 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtcdBackupS3ConfigResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_etcd_backup_s3_config" "test" {
+  bucket            = "omni-backups"
+  region            = "us-east-1"
+  endpoint          = "http://127.0.0.1:9000"
+  access_key_id     = "access"
+  secret_access_key = "secret"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_etcd_backup_s3_config.test", "id", "etcd-backup-s3-conf"),
+					resource.TestCheckResourceAttr("omni_etcd_backup_s3_config.test", "bucket", "omni-backups"),
+				),
+			},
+			{
+				ResourceName:            "omni_etcd_backup_s3_config.test",
+				ImportState:             true,
+				ImportStateId:           "etcd-backup-s3-conf",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"secret_access_key"},
+			},
+		},
+	})
+}
+
+func TestEtcdBackupS3ConfigApply(t *testing.T) {
+	st := newTestState()
+	r := &etcdBackupS3ConfigResource{provider: &omniProvider{state: st}}
+
+	plan := etcdBackupS3ConfigResourceModel{
+		Bucket:          types.StringValue("omni-backups"),
+		Region:          types.StringValue("us-east-1"),
+		AccessKeyID:     types.StringValue("access"),
+		SecretAccessKey: types.StringValue("secret"),
+	}
+
+	require.NoError(t, r.apply(t.Context(), plan))
+
+	plan.Bucket = types.StringValue("omni-backups-2")
+	plan.Region = types.StringNull()
+
+	require.NoError(t, r.apply(t.Context(), plan))
+
+	conf, err := safe.StateGetByID[*omni.EtcdBackupS3Conf](t.Context(), st, omni.EtcdBackupS3ConfID)
+	require.NoError(t, err)
+	require.Equal(t, "omni-backups-2", conf.TypedSpec().Value.Bucket)
+	require.Empty(t, conf.TypedSpec().Value.Region)
+	require.Equal(t, "secret", conf.TypedSpec().Value.SecretAccessKey)
+}
@@ -0,0 +1,157 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var _ datasource.DataSource = &etcdBackupsDataSource{}
+
+func NewEtcdBackupsDataSource() datasource.DataSource {
+	return &etcdBackupsDataSource{}
+}
+
+type etcdBackupsDataSource struct {
+	provider *omniProvider
+}
+
+type etcdBackupsDataSourceModel struct {
+	Cluster types.String      `tfsdk:"cluster"`
+	Backups []etcdBackupModel `tfsdk:"backups"`
+}
+
+type etcdBackupModel struct {
+	ID        types.String `tfsdk:"id"`
+	Snapshot  types.String `tfsdk:"snapshot"`
+	CreatedAt types.String `tfsdk:"created_at"`
+	Size      types.Int64  `tfsdk:"size"`
+}
+
+func (d *etcdBackupsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_etcd_backups"
+}
+
+func (d *etcdBackupsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "List the etcd backups of a cluster",
+		Attributes: map[string]schema.Attribute{
+			"cluster": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster",
+				Required:            true,
+			},
+			"backups": schema.ListNestedAttribute{
+				MarkdownDescription: "List of backups sorted from newest to oldest",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							MarkdownDescription: "Backup ID",
+							Computed:            true,
+						},
+						"snapshot": schema.StringAttribute{
+							MarkdownDescription: "Snapshot name, used to restore a cluster from the backup",
+							Computed:            true,
+						},
+						"created_at": schema.StringAttribute{
+							MarkdownDescription: "The RFC3339 timestamp of when the backup was created",
+							Computed:            true,
+						},
+						"size": schema.Int64Attribute{
+							MarkdownDescription: "Size of the backup in bytes",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *etcdBackupsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *etcdBackupsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data etcdBackupsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	st := d.provider.state
+
+	backups, err := listEtcdBackups(ctx, st, data.Cluster.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Etcd Backups",
+			fmt.Sprintf("Failed to get etcd backups of cluster '%s' from Omni: %s", data.Cluster.ValueString(), err),
+		)
+		return
+	}
+
+	data.Backups = []etcdBackupModel{}
+	for _, item := range backups {
+		spec := item.TypedSpec().Value
+
+		data.Backups = append(data.Backups, etcdBackupModel{
+			ID:        types.StringValue(item.Metadata().ID()),
+			Snapshot:  types.StringValue(spec.Snapshot),
+			CreatedAt: types.StringValue(spec.CreatedAt.AsTime().UTC().Format(time.RFC3339)),
+			Size:      types.Int64Value(int64(spec.Size)),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Helper functions
+
+// listEtcdBackups returns the etcd backups of the cluster sorted from newest to oldest.
+func listEtcdBackups(ctx context.Context, st state.State, clusterName string) ([]*omni.EtcdBackup, error) {
+	backups, err := safe.StateList[*omni.EtcdBackup](
+		ctx,
+		st,
+		resource.NewMetadata(resources.ExternalNamespace, omni.EtcdBackupType, "", resource.VersionUndefined),
+		state.WithLabelQuery(resource.LabelEqual(omni.LabelCluster, clusterName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items := slices.Collect(backups.All())
+	slices.SortFunc(items, func(a, b *omni.EtcdBackup) int {
+		return b.TypedSpec().Value.CreatedAt.AsTime().Compare(a.TypedSpec().Value.CreatedAt.AsTime())
+	})
+
+	return items, nil
+}
@@ -0,0 +1,245 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cosi_res "github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultBackupTimeout = "10m"
+
+var _ resource.Resource = &etcdManualBackupResource{}
+
+func NewEtcdManualBackupResource() resource.Resource {
+	return &etcdManualBackupResource{}
+}
+
+type etcdManualBackupResource struct {
+	provider *omniProvider
+}
+
+type etcdManualBackupResourceModel struct {
+	ID             types.String      `tfsdk:"id"`
+	Cluster        types.String      `tfsdk:"cluster"`
+	Triggers       map[string]string `tfsdk:"triggers"`
+	Timeout        types.String      `tfsdk:"timeout"`
+	BackupAt       types.String      `tfsdk:"backup_at"`
+	LastBackupTime types.String      `tfsdk:"last_backup_time"`
+}
+
+func (r *etcdManualBackupResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_etcd_manual_backup"
+}
+
+func (r *etcdManualBackupResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Takes an etcd backup of a cluster and waits for Omni to confirm it. " +
+			"A new backup is taken when the resource is created and every time `triggers` change.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cluster": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster to back up",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary values which take a new backup when changed",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"timeout": schema.StringAttribute{
+				MarkdownDescription: "How long to wait for the backup to complete as a Go duration. Defaults to '" + defaultBackupTimeout + "'",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(defaultBackupTimeout),
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"backup_at": schema.StringAttribute{
+				MarkdownDescription: "The RFC3339 timestamp of when the backup was requested",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"last_backup_time": schema.StringAttribute{
+				MarkdownDescription: "The RFC3339 timestamp of the backup confirmed by Omni",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *etcdManualBackupResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *etcdManualBackupResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	st := r.provider.state
+
+	var plan etcdManualBackupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	clusterName := plan.Cluster.ValueString()
+
+	timeout, err := time.ParseDuration(plan.Timeout.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Invalid timeout '%s': %v", plan.Timeout.ValueString(), err))
+		return
+	}
+
+	// Omni compares the timestamps in seconds
+	backupAt := time.Now().Truncate(time.Second)
+
+	if err := requestEtcdManualBackup(ctx, st, clusterName, backupAt); err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var last *specs.EtcdBackupStatusSpec
+
+	_, err = st.WatchFor(watchCtx, omni.NewEtcdBackupStatus(clusterName).Metadata(),
+		state.WithCondition(func(res cosi_res.Resource) (bool, error) {
+			backupStatus, ok := res.(*omni.EtcdBackupStatus)
+			if !ok {
+				return false, nil
+			}
+
+			last = backupStatus.TypedSpec().Value
+
+			return etcdBackupFinished(last, backupAt)
+		}),
+	)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Timed out waiting for the etcd backup of cluster '%s'", clusterName))
+			return
+		}
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Etcd backup of cluster '%s' failed: %v", clusterName, err))
+		return
+	}
+
+	plan.ID = plan.Cluster
+	plan.BackupAt = types.StringValue(backupAt.UTC().Format(time.RFC3339))
+	plan.LastBackupTime = types.StringValue(last.LastBackupTime.AsTime().UTC().Format(time.RFC3339))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Read keeps the state as is, the backup itself is listed by the omni_etcd_backups data source.
+func (r *etcdManualBackupResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+// Update is only called for the timeout, every other input requires replacement.
+func (r *etcdManualBackupResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan etcdManualBackupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Delete keeps the backup, it is removed by the retention policy of Omni.
+func (r *etcdManualBackupResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+// Helper functions
+func requestEtcdManualBackup(ctx context.Context, st state.State, clusterName string, backupAt time.Time) error {
+	manualBackup := omni.NewEtcdManualBackup(clusterName)
+
+	_, err := st.Get(ctx, manualBackup.Metadata())
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return fmt.Errorf("failed to read the manual backup of cluster '%s': %v", clusterName, err)
+		}
+
+		manualBackup.TypedSpec().Value.BackupAt = timestamppb.New(backupAt)
+
+		if err := st.Create(ctx, manualBackup); err != nil {
+			return fmt.Errorf("failed to request the manual backup of cluster '%s': %v", clusterName, err)
+		}
+
+		return nil
+	}
+
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, manualBackup.Metadata(), func(res *omni.EtcdManualBackup) error {
+		res.TypedSpec().Value.BackupAt = timestamppb.New(backupAt)
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to request the manual backup of cluster '%s': %v", clusterName, err)
+	}
+
+	return nil
+}
+
+func etcdBackupFinished(status *specs.EtcdBackupStatusSpec, backupAt time.Time) (bool, error) {
+	if status.LastBackupAttempt == nil || status.LastBackupAttempt.AsTime().Before(backupAt) {
+		return false, nil
+	}
+
+	switch status.Status {
+	case specs.EtcdBackupStatusSpec_Ok:
+		return status.LastBackupTime != nil && !status.LastBackupTime.AsTime().Before(backupAt), nil
+	case specs.EtcdBackupStatusSpec_Error:
+		return false, errors.New(status.Error)
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,91 @@
+package omni
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestEtcdManualBackupResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_etcd_manual_backup" "test" {
+  cluster = "talos-default"
+}
+
+data "omni_etcd_backups" "test" {
+  cluster = omni_etcd_manual_backup.test.cluster
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("omni_etcd_manual_backup.test", "last_backup_time"),
+					resource.TestCheckResourceAttrSet("data.omni_etcd_backups.test", "backups.0.snapshot"),
+				),
+			},
+		},
+	})
+}
+
+func TestEtcdBackupFinished(t *testing.T) {
+	backupAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		status   *specs.EtcdBackupStatusSpec
+		finished bool
+		err      string
+	}{
+		{
+			name: "previous backup",
+			status: &specs.EtcdBackupStatusSpec{
+				Status:            specs.EtcdBackupStatusSpec_Ok,
+				LastBackupAttempt: timestamppb.New(backupAt.Add(-time.Hour)),
+				LastBackupTime:    timestamppb.New(backupAt.Add(-time.Hour)),
+			},
+		},
+		{
+			name: "running",
+			status: &specs.EtcdBackupStatusSpec{
+				Status:            specs.EtcdBackupStatusSpec_Running,
+				LastBackupAttempt: timestamppb.New(backupAt),
+			},
+		},
+		{
+			name: "done",
+			status: &specs.EtcdBackupStatusSpec{
+				Status:            specs.EtcdBackupStatusSpec_Ok,
+				LastBackupAttempt: timestamppb.New(backupAt.Add(time.Second)),
+				LastBackupTime:    timestamppb.New(backupAt.Add(time.Second)),
+			},
+			finished: true,
+		},
+		{
+			name: "failed",
+			status: &specs.EtcdBackupStatusSpec{
+				Status:            specs.EtcdBackupStatusSpec_Error,
+				LastBackupAttempt: timestamppb.New(backupAt),
+				Error:             "bucket not found",
+			},
+			err: "bucket not found",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			finished, err := etcdBackupFinished(tc.status, backupAt)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.finished, finished)
+		})
+	}
+}
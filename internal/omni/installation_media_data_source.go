@@ -8,21 +8,37 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
-	"github.com/cosi-project/runtime/pkg/resource"
 	"github.com/cosi-project/runtime/pkg/safe"
-	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/constants"
+	"github.com/siderolabs/omni/client/pkg/meta"
 	"github.com/siderolabs/omni/client/pkg/omni/resources"
 	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
 )
 
-var _ datasource.DataSource = &installationMediaDataSource{}
+const (
+	// legacyISOImageName is the image name selecting the ISO of the architecture
+	legacyISOImageName = "iso"
+
+	// defaultImageExtension is the format selected for an image name published in several formats
+	defaultImageExtension = "raw.xz"
+)
+
+var (
+	_ datasource.DataSource                     = &installationMediaDataSource{}
+	_ datasource.DataSourceWithConfigValidators = &installationMediaDataSource{}
+)
 
 func NewInstallationMediaDataSource() datasource.DataSource {
 	return &installationMediaDataSource{}
@@ -37,13 +53,40 @@ type installationMediaDataSourceModel struct {
 	Arch                 types.String   `tfsdk:"arch"`
 	TalosVersion         types.String   `tfsdk:"talos_version"`
 	ImageName            types.String   `tfsdk:"image_name"`
+	MediaID              types.String   `tfsdk:"media_id"`
+	Profile              types.String   `tfsdk:"profile"`
+	Overlay              types.String   `tfsdk:"overlay"`
+	Extension            types.String   `tfsdk:"extension"`
 	Extensions           []types.String `tfsdk:"extensions"`
 	ExtraKernelArgs      []types.String `tfsdk:"extra_kernel_args"`
 	SecureBoot           types.Bool     `tfsdk:"secure_boot"`
 	SiderolinkGRPCTunnel types.Bool     `tfsdk:"use_siderolink_grpc_tunnel"`
 
-	Schematic types.String `tfsdk:"schematic"`
-	PXEUrl    types.String `tfsdk:"pxe_url"`
+	MetaValues    map[string]string `tfsdk:"meta_values"`
+	NetworkConfig types.String      `tfsdk:"network_config"`
+
+	Schematic        types.String `tfsdk:"schematic"`
+	PXEUrl           types.String `tfsdk:"pxe_url"`
+	ImageURL         types.String `tfsdk:"image_url"`
+	ISOUrl           types.String `tfsdk:"iso_url"`
+	SecureBootISOUrl types.String `tfsdk:"secure_boot_iso_url"`
+	RawURL           types.String `tfsdk:"raw_url"`
+	QCOW2Url         types.String `tfsdk:"qcow2_url"`
+	CloudImageURL    types.String `tfsdk:"cloud_image_url"`
+	AMIUrl           types.String `tfsdk:"ami_url"`
+	InstallerImage   types.String `tfsdk:"installer_image"`
+}
+
+// installationMediaURLs holds the Image Factory download URLs of a schematic.
+type installationMediaURLs struct {
+	image          string
+	iso            string
+	secureBootISO  string
+	raw            string
+	qcow2          string
+	cloudImage     string
+	ami            string
+	installerImage string
 }
 
 func (d *installationMediaDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -52,10 +95,12 @@ func (d *installationMediaDataSource) Metadata(ctx context.Context, req datasour
 
 func (d *installationMediaDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "Generate the schematic and pxe url of the installation media",
+		MarkdownDescription: "Generate the schematic and pxe url of the installation media. " +
+			"Overlays and join tokens can not be baked into the schematic yet, the schematic API of the Omni client the provider is built against has neither: " +
+			"single board computer images are selected with `overlay` or `media_id` and the machines join with the default join token of the Omni instance.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
-				MarkdownDescription: "ID",
+				MarkdownDescription: "The generated schematic ID",
 				Computed:            true,
 			},
 			"arch": schema.StringAttribute{
@@ -73,8 +118,26 @@ func (d *installationMediaDataSource) Schema(ctx context.Context, req datasource
 				ElementType:         types.StringType,
 			},
 			"image_name": schema.StringAttribute{
-				MarkdownDescription: "Image name",
-				Required:            true,
+				MarkdownDescription: "Image name, e.g. 'aws', selecting the media with the ID `<image_name>-<arch>.<extension>`. " +
+					"When the image is published in several formats and `extension` is not set, the `" + defaultImageExtension + "` one is selected. " +
+					"The legacy name 'iso' selects the ISO of the architecture",
+				Optional: true,
+			},
+			"media_id": schema.StringAttribute{
+				MarkdownDescription: "The exact ID of the installation media, see the `omni_installation_medias` data source",
+				Optional:            true,
+			},
+			"profile": schema.StringAttribute{
+				MarkdownDescription: "The platform profile of the installation media, e.g. 'metal', 'aws' or 'nocloud'",
+				Optional:            true,
+			},
+			"overlay": schema.StringAttribute{
+				MarkdownDescription: "The overlay of the installation media, used to select a single board computer image, e.g. 'rpi_generic'. When selecting by `profile` without an overlay, single board computer images are excluded",
+				Optional:            true,
+			},
+			"extension": schema.StringAttribute{
+				MarkdownDescription: "The file extension of the installation media, e.g. 'iso' or 'raw.xz'",
+				Optional:            true,
 			},
 			"secure_boot": schema.BoolAttribute{
 				MarkdownDescription: "Enable secure boot",
@@ -88,6 +151,18 @@ func (d *installationMediaDataSource) Schema(ctx context.Context, req datasource
 				MarkdownDescription: "Use Siderolink GRPC tunnel",
 				Optional:            true,
 			},
+			"meta_values": schema.MapAttribute{
+				MarkdownDescription: "META values baked into the image, keyed by the META key number",
+				Optional:            true,
+				ElementType:         types.StringType,
+				Validators: []validator.Map{
+					metaValuesValidator{},
+				},
+			},
+			"network_config": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Static network configuration in the Talos platform network config YAML format, stored in META key %d", meta.MetalNetworkPlatformConfig),
+				Optional:            true,
+			},
 			"schematic": schema.StringAttribute{
 				MarkdownDescription: "Generated schematic",
 				Computed:            true,
@@ -96,10 +171,52 @@ func (d *installationMediaDataSource) Schema(ctx context.Context, req datasource
 				MarkdownDescription: "Generated pxe url",
 				Computed:            true,
 			},
+			"image_url": schema.StringAttribute{
+				MarkdownDescription: "Download url of the selected installation media",
+				Computed:            true,
+			},
+			"iso_url": schema.StringAttribute{
+				MarkdownDescription: "Download url of the ISO",
+				Computed:            true,
+			},
+			"secure_boot_iso_url": schema.StringAttribute{
+				MarkdownDescription: "Download url of the SecureBoot ISO. Empty if the selected installation media doesn't support SecureBoot",
+				Computed:            true,
+			},
+			"raw_url": schema.StringAttribute{
+				MarkdownDescription: "Download url of the raw disk image for the platform of the selected installation media",
+				Computed:            true,
+			},
+			"qcow2_url": schema.StringAttribute{
+				MarkdownDescription: "Download url of the qcow2 disk image for the platform of the selected installation media",
+				Computed:            true,
+			},
+			"cloud_image_url": schema.StringAttribute{
+				MarkdownDescription: "Download url of the disk image of the selected cloud platform. Empty for the metal platform",
+				Computed:            true,
+			},
+			"ami_url": schema.StringAttribute{
+				MarkdownDescription: "Download url of the AWS disk image used to import an AMI",
+				Computed:            true,
+			},
+			"installer_image": schema.StringAttribute{
+				MarkdownDescription: "Installer image reference, e.g. `factory.talos.dev/installer/<schematic>:<version>`",
+				Computed:            true,
+			},
 		},
 	}
 }
 
+func (d *installationMediaDataSource) ConfigValidators(ctx context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		datasourcevalidator.AtLeastOneOf(
+			path.MatchRoot("image_name"),
+			path.MatchRoot("media_id"),
+			path.MatchRoot("profile"),
+		),
+	}
+}
+
 func (d *installationMediaDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
@@ -129,14 +246,9 @@ func (d *installationMediaDataSource) Read(ctx context.Context, req datasource.R
 		data.Arch = types.StringValue("amd64")
 	}
 
-	st := d.provider.client.Omni().State()
-
-	options := []state.ListOption{
-		state.WithIDQuery(resource.IDRegexpMatch(regexp.MustCompile(buildRegex(data)))),
-	}
-
-	image, err := safe.StateList[*omni.InstallationMedia](ctx, st, omni.NewInstallationMedia(resources.EphemeralNamespace, "").Metadata(), options...)
+	st := d.provider.state
 
+	medias, err := safe.StateList[*omni.InstallationMedia](ctx, st, omni.NewInstallationMedia(resources.EphemeralNamespace, "").Metadata())
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to Get Image",
@@ -145,11 +257,15 @@ func (d *installationMediaDataSource) Read(ctx context.Context, req datasource.R
 		return
 	}
 
-	if image.Len() == 0 {
-		resp.Diagnostics.AddError(
-			"No image found",
-			fmt.Sprintf("No image found with the criteria given: %s", err),
-		)
+	media, err := selectInstallationMedia(slices.Collect(medias.All()), data)
+	if err != nil {
+		resp.Diagnostics.AddError("No image found", err.Error())
+		return
+	}
+
+	metaValues, err := schematicMetaValues(data.MetaValues, data.NetworkConfig)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid META values", err.Error())
 		return
 	}
 
@@ -163,25 +279,19 @@ func (d *installationMediaDataSource) Read(ctx context.Context, req datasource.R
 		extentions = append(extentions, v.ValueString())
 	}
 
-	grpcTunnel := management.CreateSchematicRequest_AUTO
-
-	if !data.SiderolinkGRPCTunnel.IsNull() {
-		switch data.SiderolinkGRPCTunnel.ValueBool() {
-		case true:
-			grpcTunnel = management.CreateSchematicRequest_ENABLED
-		case false:
-			grpcTunnel = management.CreateSchematicRequest_DISABLED
-		}
+	if err := validateExtensions(ctx, st, data.TalosVersion.ValueString(), extentions); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("extensions"), "Invalid Extensions", err.Error())
+		return
 	}
 
-	schematic, err := d.provider.client.Management().CreateSchematic(ctx, &management.CreateSchematicRequest{
-		MetaValues:               map[uint32]string{},
+	schematic, err := d.provider.management.CreateSchematic(ctx, &management.CreateSchematicRequest{
+		MetaValues:               metaValues,
 		ExtraKernelArgs:          extraKernelArgs,
 		Extensions:               extentions,
-		MediaId:                  image.Get(0).Metadata().ID(),
+		MediaId:                  media.Metadata().ID(),
 		SecureBoot:               data.SecureBoot.ValueBool(),
 		TalosVersion:             data.TalosVersion.ValueString(),
-		SiderolinkGrpcTunnelMode: grpcTunnel,
+		SiderolinkGrpcTunnelMode: grpcTunnelMode(data.SiderolinkGRPCTunnel),
 	})
 
 	if err != nil {
@@ -192,28 +302,170 @@ func (d *installationMediaDataSource) Read(ctx context.Context, req datasource.R
 		return
 	}
 
+	factoryURL := constants.ImageFactoryBaseURL
+
+	features, err := safe.StateGetByID[*omni.FeaturesConfig](ctx, st, omni.FeaturesConfigID)
+	if err == nil && features.TypedSpec().Value.ImageFactoryBaseUrl != "" {
+		factoryURL = features.TypedSpec().Value.ImageFactoryBaseUrl
+	}
+
+	urls := buildInstallationMediaURLs(factoryURL, schematic.SchematicId, data.TalosVersion.ValueString(), media.TypedSpec().Value, data.SecureBoot.ValueBool())
+
+	data.ID = types.StringValue(schematic.SchematicId)
 	data.Schematic = types.StringValue(schematic.SchematicId)
 	data.PXEUrl = types.StringValue(schematic.PxeUrl)
+	data.ImageURL = types.StringValue(urls.image)
+	data.ISOUrl = types.StringValue(urls.iso)
+	data.SecureBootISOUrl = types.StringValue(urls.secureBootISO)
+	data.RawURL = types.StringValue(urls.raw)
+	data.QCOW2Url = types.StringValue(urls.qcow2)
+	data.CloudImageURL = types.StringValue(urls.cloudImage)
+	data.AMIUrl = types.StringValue(urls.ami)
+	data.InstallerImage = types.StringValue(urls.installerImage)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
-// Helper function to create a regex that is used to filter for the image
-func buildRegex(data installationMediaDataSourceModel) string {
-	var regexBuilder strings.Builder
+// Helper function to build the Image Factory download URLs of the schematic for the selected installation media
+func buildInstallationMediaURLs(factoryURL, schematicID, talosVersion string, media *specs.InstallationMediaSpec, secureBoot bool) installationMediaURLs {
+	factoryURL = strings.TrimSuffix(factoryURL, "/")
+	version := "v" + strings.TrimPrefix(talosVersion, "v")
+	imageBase := factoryURL + "/image/" + schematicID + "/" + version + "/"
+	arch := media.Architecture
 
-	if data.ImageName.ValueString() == "iso" {
-		regexBuilder.WriteString(".*")
-		regexBuilder.WriteString(strings.ToLower(data.Arch.ValueString()))
-		regexBuilder.WriteString("\\.iso$")
-		return regexBuilder.String()
+	suffix := ""
+	if secureBoot && !media.NoSecureBoot {
+		suffix = "-secureboot"
 	}
 
+	urls := installationMediaURLs{
+		image: imageBase + media.SrcFilePrefix + suffix + "." + media.Extension,
+		iso:   imageBase + "metal-" + arch + ".iso",
+		raw:   imageBase + media.Profile + "-" + arch + ".raw.xz",
+		qcow2: imageBase + media.Profile + "-" + arch + ".qcow2",
+		ami:   imageBase + "aws-" + arch + ".raw.xz",
+	}
+
+	if !media.NoSecureBoot {
+		urls.secureBootISO = imageBase + "metal-" + arch + "-secureboot.iso"
+	}
+
+	if media.Profile != "metal" {
+		urls.cloudImage = imageBase + media.SrcFilePrefix + "." + media.Extension
+	}
+
+	registry := strings.TrimPrefix(strings.TrimPrefix(factoryURL, "https://"), "http://")
+	urls.installerImage = registry + "/installer" + suffix + "/" + schematicID + ":" + version
+
+	return urls
+}
+
+// Helper function to merge the META values with the network config helper attribute
+func schematicMetaValues(values map[string]string, networkConfig types.String) (map[uint32]string, error) {
+	metaValues, err := parseMetaValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	if networkConfig.IsNull() || networkConfig.IsUnknown() {
+		return metaValues, nil
+	}
+
+	if _, ok := metaValues[meta.MetalNetworkPlatformConfig]; ok {
+		return nil, fmt.Errorf("META key %d is set by both meta_values and network_config", meta.MetalNetworkPlatformConfig)
+	}
+
+	metaValues[meta.MetalNetworkPlatformConfig] = networkConfig.ValueString()
+
+	return metaValues, nil
+}
+
+// Helper function to map the optional tunnel setting to the schematic request mode
+func grpcTunnelMode(tunnel types.Bool) management.CreateSchematicRequest_SiderolinkGRPCTunnelMode {
+	if tunnel.IsNull() || tunnel.IsUnknown() {
+		return management.CreateSchematicRequest_AUTO
+	}
+
+	if tunnel.ValueBool() {
+		return management.CreateSchematicRequest_ENABLED
+	}
+
+	return management.CreateSchematicRequest_DISABLED
+}
+
+// Helper function to select exactly one installation media matching all the given criteria
+func selectInstallationMedia(medias []*omni.InstallationMedia, data installationMediaDataSourceModel) (*omni.InstallationMedia, error) {
+	var nameRegex *regexp.Regexp
+	if data.ImageName.ValueString() == legacyISOImageName {
+		nameRegex = regexp.MustCompile(buildRegex(data))
+	}
+
+	var matches []*omni.InstallationMedia
+
+	for _, media := range medias {
+		spec := media.TypedSpec().Value
+
+		switch {
+		case data.MediaID.ValueString() != "" && media.Metadata().ID() != data.MediaID.ValueString():
+			continue
+		case nameRegex != nil && !nameRegex.MatchString(media.Metadata().ID()):
+			continue
+		case nameRegex == nil && data.ImageName.ValueString() != "" && !strings.HasPrefix(strings.ToLower(media.Metadata().ID()), imageNamePrefix(data)):
+			continue
+		case data.MediaID.ValueString() == "" && !strings.EqualFold(spec.Architecture, data.Arch.ValueString()):
+			continue
+		case data.Profile.ValueString() != "" && spec.Profile != data.Profile.ValueString():
+			continue
+		case !data.Overlay.IsNull() && spec.Overlay != data.Overlay.ValueString():
+			continue
+		case data.Overlay.IsNull() && data.Profile.ValueString() != "" && spec.Overlay != "":
+			continue
+		case data.Extension.ValueString() != "" && spec.Extension != data.Extension.ValueString():
+			continue
+		case data.SecureBoot.ValueBool() && spec.NoSecureBoot:
+			continue
+		}
+
+		matches = append(matches, media)
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no installation media found with the criteria given")
+	case 1:
+		return matches[0], nil
+	}
+
+	// an image published in several formats is selected in its default one, unless the extension is given
+	if data.ImageName.ValueString() != "" && data.Extension.ValueString() == "" {
+		defaults := slices.DeleteFunc(slices.Clone(matches), func(media *omni.InstallationMedia) bool {
+			return media.TypedSpec().Value.Extension != defaultImageExtension
+		})
+		if len(defaults) == 1 {
+			return defaults[0], nil
+		}
+	}
+
+	ids := make([]string, 0, len(matches))
+	for _, media := range matches {
+		ids = append(ids, media.Metadata().ID())
+	}
+
+	return nil, fmt.Errorf("the criteria given match multiple installation media (%s), set media_id to select one of them", strings.Join(ids, ", "))
+}
+
+// Helper function to create a regex that is used to filter for the ISO of the legacy "iso" image name
+func buildRegex(data installationMediaDataSourceModel) string {
+	var regexBuilder strings.Builder
+
 	regexBuilder.WriteString(".*")
-	regexBuilder.WriteString(strings.ToLower(data.ImageName.ValueString()))
-	regexBuilder.WriteString(".*")
-	regexBuilder.WriteString(strings.ToLower(data.Arch.ValueString()))
-	regexBuilder.WriteString(".*")
+	regexBuilder.WriteString(regexp.QuoteMeta(strings.ToLower(data.Arch.ValueString())))
+	regexBuilder.WriteString("\\.iso$")
 
 	return regexBuilder.String()
 }
+
+// imageNamePrefix returns the start of the IDs of the media of the image, "<image_name>-<arch>." followed by the extension
+func imageNamePrefix(data installationMediaDataSourceModel) string {
+	return strings.ToLower(data.ImageName.ValueString()) + "-" + strings.ToLower(data.Arch.ValueString()) + "."
+}
@@ -1,14 +1,27 @@
 package omni
 
 import (
+	"regexp"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stathis-ditc/terraform-provider-omni/internal/omnitest"
 )
 
 func TestInstallationMediaDataSource(t *testing.T) {
+	schematicID := omnitest.SchematicID(&management.CreateSchematicRequest{
+		SiderolinkGrpcTunnelMode: grpcTunnelMode(types.BoolNull()),
+	})
+
 	resource.Test(t, resource.TestCase{
-		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ProtoV6ProviderFactories: testAccOfflineProviderFactories(t),
 		Steps: []resource.TestStep{
 			{
 				Config: providerConfig + `
@@ -21,8 +34,10 @@ output "iso_schematic" {
 }
 		  `,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("data.omni_installation_media.basic", "schematic", "d23d7a50ced403dfc4a9405b2863893f1f3ce4792f601f227f27ccb019c416ca"),
-					resource.TestCheckResourceAttr("data.omni_installation_media.basic", "pxe_url", "https://pxe.factory.talos.dev/pxe/d23d7a50ced403dfc4a9405b2863893f1f3ce4792f601f227f27ccb019c416ca/v1.9.5/metal-amd64"),
+					resource.TestCheckResourceAttr("data.omni_installation_media.basic", "schematic", schematicID),
+					resource.TestCheckResourceAttr("data.omni_installation_media.basic", "pxe_url", "https://pxe.factory.talos.dev/pxe/"+schematicID+"/v1.9.5/metal-amd64"),
+					resource.TestCheckResourceAttr("data.omni_installation_media.basic", "iso_url", "https://factory.talos.dev/image/"+schematicID+"/v1.9.5/metal-amd64.iso"),
+					resource.TestCheckResourceAttr("data.omni_installation_media.basic", "installer_image", "factory.talos.dev/installer/"+schematicID+":v1.9.5"),
 				),
 			},
 			{
@@ -36,17 +51,179 @@ data "omni_installation_media" "detailed" {
     "ip=1.2.3.4::1.2.3.1:255.255.255.0::ens18:off:1.2.3.53"
   ]
   extensions = [ # Optional: extensions
-    "docker",
-    "k8s"
+    "siderolabs/iscsi-tools",
+    "siderolabs/qemu-guest-agent"
   ]
   use_siderolink_grpc_tunnel = false # Optional: Use SideroLink GRPC tunnel
 }`,
 				Check: resource.ComposeAggregateTestCheckFunc(
-					resource.TestCheckResourceAttr("data.omni_installation_media.detailed", "schematic", "17745c21ca7335d5d654f09e447d30f3bcdd57dc071252d470199167a1aeb968"),
-					resource.TestCheckResourceAttr("data.omni_installation_media.detailed", "pxe_url", "https://pxe.factory.talos.dev/pxe/17745c21ca7335d5d654f09e447d30f3bcdd57dc071252d470199167a1aeb968/v1.9.5/metal-amd64"),
+					resource.TestMatchResourceAttr("data.omni_installation_media.detailed", "schematic", regexp.MustCompile(`^[0-9a-f]{64}$`)),
+					resource.TestMatchResourceAttr("data.omni_installation_media.detailed", "pxe_url", regexp.MustCompile(`^https://pxe\.factory\.talos\.dev/pxe/[0-9a-f]{64}/v1\.9\.5/metal-amd64$`)),
 				),
 			},
+			{
+				Config: providerConfig + `
+data "omni_installation_media" "invalid" {
+  image_name    = "iso"
+  talos_version = "v1.9.5"
+  extensions    = ["qemu-guest-agnt"]
+}`,
+				ExpectError: regexp.MustCompile(`did you mean 'siderolabs/qemu-guest-agent'`),
+			},
 		},
 	})
 
 }
+
+func TestBuildInstallationMediaURLs(t *testing.T) {
+	media := &specs.InstallationMediaSpec{
+		Architecture:  "amd64",
+		Profile:       "aws",
+		SrcFilePrefix: "aws-amd64",
+		Extension:     "raw.xz",
+	}
+
+	urls := buildInstallationMediaURLs("https://factory.talos.dev/", "abc", "1.9.5", media, true)
+
+	require.Equal(t, "https://factory.talos.dev/image/abc/v1.9.5/aws-amd64-secureboot.raw.xz", urls.image)
+	require.Equal(t, "https://factory.talos.dev/image/abc/v1.9.5/metal-amd64.iso", urls.iso)
+	require.Equal(t, "https://factory.talos.dev/image/abc/v1.9.5/metal-amd64-secureboot.iso", urls.secureBootISO)
+	require.Equal(t, "https://factory.talos.dev/image/abc/v1.9.5/aws-amd64.raw.xz", urls.raw)
+	require.Equal(t, "https://factory.talos.dev/image/abc/v1.9.5/aws-amd64.qcow2", urls.qcow2)
+	require.Equal(t, "https://factory.talos.dev/image/abc/v1.9.5/aws-amd64.raw.xz", urls.cloudImage)
+	require.Equal(t, "https://factory.talos.dev/image/abc/v1.9.5/aws-amd64.raw.xz", urls.ami)
+	require.Equal(t, "factory.talos.dev/installer-secureboot/abc:v1.9.5", urls.installerImage)
+
+	media = &specs.InstallationMediaSpec{
+		Architecture:  "arm64",
+		Profile:       "metal",
+		SrcFilePrefix: "metal-rpi_generic-arm64",
+		Extension:     "raw.xz",
+		NoSecureBoot:  true,
+	}
+
+	urls = buildInstallationMediaURLs("https://factory.example.com", "abc", "v1.9.5", media, true)
+
+	require.Equal(t, "https://factory.example.com/image/abc/v1.9.5/metal-rpi_generic-arm64.raw.xz", urls.image)
+	require.Empty(t, urls.secureBootISO)
+	require.Empty(t, urls.cloudImage)
+	require.Equal(t, "factory.example.com/installer/abc:v1.9.5", urls.installerImage)
+}
+
+func TestSelectInstallationMedia(t *testing.T) {
+	newMedia := func(id string, spec *specs.InstallationMediaSpec) *omni.InstallationMedia {
+		media := omni.NewInstallationMedia(resources.EphemeralNamespace, id)
+		media.TypedSpec().Value = spec
+
+		return media
+	}
+
+	medias := []*omni.InstallationMedia{
+		newMedia("iso-amd64.iso", &specs.InstallationMediaSpec{Architecture: "amd64", Profile: "metal", Extension: "iso"}),
+		newMedia("metal-arm64.raw.xz", &specs.InstallationMediaSpec{Architecture: "arm64", Profile: "metal", Extension: "raw.xz"}),
+		newMedia("rpi_generic-arm64.raw.xz", &specs.InstallationMediaSpec{Architecture: "arm64", Profile: "metal", Extension: "raw.xz", Overlay: "rpi_generic", NoSecureBoot: true}),
+		newMedia("aws-amd64.raw.xz", &specs.InstallationMediaSpec{Architecture: "amd64", Profile: "aws", Extension: "raw.xz"}),
+		newMedia("aws-amd64.qcow2", &specs.InstallationMediaSpec{Architecture: "amd64", Profile: "aws", Extension: "qcow2"}),
+	}
+
+	for _, tc := range []struct {
+		name     string
+		data     installationMediaDataSourceModel
+		expected string
+		err      string
+	}{
+		{
+			name:     "media id",
+			data:     installationMediaDataSourceModel{MediaID: types.StringValue("rpi_generic-arm64.raw.xz"), Arch: types.StringValue("amd64")},
+			expected: "rpi_generic-arm64.raw.xz",
+		},
+		{
+			name:     "legacy image name",
+			data:     installationMediaDataSourceModel{ImageName: types.StringValue("iso"), Arch: types.StringValue("amd64")},
+			expected: "iso-amd64.iso",
+		},
+		{
+			name:     "profile excludes overlays",
+			data:     installationMediaDataSourceModel{Profile: types.StringValue("metal"), Arch: types.StringValue("arm64")},
+			expected: "metal-arm64.raw.xz",
+		},
+		{
+			name:     "overlay",
+			data:     installationMediaDataSourceModel{Profile: types.StringValue("metal"), Overlay: types.StringValue("rpi_generic"), Arch: types.StringValue("arm64")},
+			expected: "rpi_generic-arm64.raw.xz",
+		},
+		{
+			name: "secure boot not supported",
+			data: installationMediaDataSourceModel{MediaID: types.StringValue("rpi_generic-arm64.raw.xz"), SecureBoot: types.BoolValue(true)},
+			err:  "no installation media found",
+		},
+		{
+			name: "ambiguous",
+			data: installationMediaDataSourceModel{Profile: types.StringValue("aws"), Arch: types.StringValue("amd64")},
+			err:  "multiple installation media (aws-amd64.raw.xz, aws-amd64.qcow2)",
+		},
+		{
+			name:     "image name selects the default format",
+			data:     installationMediaDataSourceModel{ImageName: types.StringValue("aws"), Arch: types.StringValue("amd64")},
+			expected: "aws-amd64.raw.xz",
+		},
+		{
+			name:     "image name with extension",
+			data:     installationMediaDataSourceModel{ImageName: types.StringValue("aws"), Extension: types.StringValue("qcow2"), Arch: types.StringValue("amd64")},
+			expected: "aws-amd64.qcow2",
+		},
+		{
+			name:     "image name is matched exactly",
+			data:     installationMediaDataSourceModel{ImageName: types.StringValue("metal"), Arch: types.StringValue("arm64")},
+			expected: "metal-arm64.raw.xz",
+		},
+		{
+			name: "image name is not a substring",
+			data: installationMediaDataSourceModel{ImageName: types.StringValue("rpi"), Arch: types.StringValue("arm64")},
+			err:  "no installation media found",
+		},
+		{
+			name:     "extension",
+			data:     installationMediaDataSourceModel{Profile: types.StringValue("aws"), Extension: types.StringValue("qcow2"), Arch: types.StringValue("amd64")},
+			expected: "aws-amd64.qcow2",
+		},
+		{
+			name: "image name is matched literally",
+			data: installationMediaDataSourceModel{ImageName: types.StringValue("aws(["), Arch: types.StringValue("amd64")},
+			err:  "no installation media found",
+		},
+		{
+			name: "image name dot is not a wildcard",
+			data: installationMediaDataSourceModel{ImageName: types.StringValue("raw.xz"), Arch: types.StringValue("a.d64")},
+			err:  "no installation media found",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			media, err := selectInstallationMedia(medias, tc.data)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, media.Metadata().ID())
+		})
+	}
+}
+
+func TestSchematicMetaValues(t *testing.T) {
+	values, err := schematicMetaValues(map[string]string{"13": "value"}, types.StringValue("addresses: []"))
+	require.NoError(t, err)
+	require.Equal(t, map[uint32]string{13: "value", 10: "addresses: []"}, values)
+
+	values, err = schematicMetaValues(nil, types.StringNull())
+	require.NoError(t, err)
+	require.Empty(t, values)
+
+	_, err = schematicMetaValues(map[string]string{"10": "addresses: []"}, types.StringValue("addresses: []"))
+	require.ErrorContains(t, err, "set by both")
+
+	_, err = schematicMetaValues(map[string]string{"6": "value"}, types.StringNull())
+	require.ErrorContains(t, err, "reserved")
+}
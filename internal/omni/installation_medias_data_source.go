@@ -0,0 +1,134 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var _ datasource.DataSource = &installationMediasDataSource{}
+
+func NewInstallationMediasDataSource() datasource.DataSource {
+	return &installationMediasDataSource{}
+}
+
+type installationMediasDataSource struct {
+	provider *omniProvider
+}
+
+type installationMediasDataSourceModel struct {
+	Medias []installationMediaModel `tfsdk:"medias"`
+}
+
+type installationMediaModel struct {
+	ID                  types.String `tfsdk:"id"`
+	Name                types.String `tfsdk:"name"`
+	Architecture        types.String `tfsdk:"architecture"`
+	Profile             types.String `tfsdk:"profile"`
+	Overlay             types.String `tfsdk:"overlay"`
+	Extension           types.String `tfsdk:"extension"`
+	ContentType         types.String `tfsdk:"content_type"`
+	SecureBootSupported types.Bool   `tfsdk:"secure_boot_supported"`
+	MinTalosVersion     types.String `tfsdk:"min_talos_version"`
+}
+
+func (d *installationMediasDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_installation_medias"
+}
+
+func (d *installationMediasDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	computedString := func(description string) schema.StringAttribute {
+		return schema.StringAttribute{
+			MarkdownDescription: description,
+			Computed:            true,
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "List all installation media available in Omni",
+		Attributes: map[string]schema.Attribute{
+			"medias": schema.ListNestedAttribute{
+				MarkdownDescription: "List of all installation media",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id":                computedString("Installation media ID, usable as `media_id` of the `omni_installation_media` data source"),
+						"name":              computedString("Human readable name"),
+						"architecture":      computedString("Architecture"),
+						"profile":           computedString("Platform profile"),
+						"overlay":           computedString("Overlay used for single board computers"),
+						"extension":         computedString("File extension"),
+						"content_type":      computedString("Content type of the image"),
+						"min_talos_version": computedString("Minimum supported Talos version"),
+						"secure_boot_supported": schema.BoolAttribute{
+							MarkdownDescription: "Whether a SecureBoot image can be generated",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *installationMediasDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *installationMediasDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data installationMediasDataSourceModel
+
+	st := d.provider.state
+
+	medias, err := safe.StateList[*omni.InstallationMedia](ctx, st, omni.NewInstallationMedia(resources.EphemeralNamespace, "").Metadata())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Images",
+			fmt.Sprintf("Failed to get installation media from Omni: %s", err),
+		)
+		return
+	}
+
+	data.Medias = []installationMediaModel{}
+	for item := range medias.All() {
+		spec := item.TypedSpec().Value
+
+		data.Medias = append(data.Medias, installationMediaModel{
+			ID:                  types.StringValue(item.Metadata().ID()),
+			Name:                types.StringValue(spec.Name),
+			Architecture:        types.StringValue(spec.Architecture),
+			Profile:             types.StringValue(spec.Profile),
+			Overlay:             types.StringValue(spec.Overlay),
+			Extension:           types.StringValue(spec.Extension),
+			ContentType:         types.StringValue(spec.ContentType),
+			SecureBootSupported: types.BoolValue(!spec.NoSecureBoot),
+			MinTalosVersion:     types.StringValue(spec.MinTalosVersion),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,29 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestInstallationMediasDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccOfflineProviderFactories(t),
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "omni_installation_medias" "all" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					// the medias seeded by testFixtures, listed by ID
+					resource.TestCheckResourceAttr("data.omni_installation_medias.all", "medias.#", "4"),
+					resource.TestCheckResourceAttr("data.omni_installation_medias.all", "medias.0.id", "aws-amd64.raw.xz"),
+					resource.TestCheckResourceAttr("data.omni_installation_medias.all", "medias.0.architecture", "amd64"),
+					resource.TestCheckResourceAttr("data.omni_installation_medias.all", "medias.3.id", "rpi_generic-arm64.raw.xz"),
+					resource.TestCheckResourceAttr("data.omni_installation_medias.all", "medias.3.overlay", "rpi_generic"),
+					resource.TestCheckResourceAttr("data.omni_installation_medias.all", "medias.3.secure_boot_supported", "false"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,224 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/siderolabs/omni/client/api/omni/management"
+)
+
+var (
+	_ resource.Resource               = &kubernetesManifestsSyncResource{}
+	_ resource.ResourceWithModifyPlan = &kubernetesManifestsSyncResource{}
+)
+
+func NewKubernetesManifestsSyncResource() resource.Resource {
+	return &kubernetesManifestsSyncResource{}
+}
+
+type kubernetesManifestsSyncResource struct {
+	provider *omniProvider
+}
+
+type kubernetesManifestsSyncResourceModel struct {
+	ID              types.String      `tfsdk:"id"`
+	Cluster         types.String      `tfsdk:"cluster"`
+	Triggers        map[string]string `tfsdk:"triggers"`
+	DryRun          types.Bool        `tfsdk:"dry_run"`
+	SyncedManifests []types.String    `tfsdk:"synced_manifests"`
+}
+
+func (r *kubernetesManifestsSyncResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_kubernetes_manifests_sync"
+}
+
+func (r *kubernetesManifestsSyncResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Syncs the bootstrap Kubernetes manifests of a cluster, e.g. CoreDNS, kube-proxy and flannel. " +
+			"The sync runs when the resource is created and every time `triggers` change. " +
+			"During plan a dry run reports the manifests which would change as warnings.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cluster": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary values which run the sync again when changed, e.g. the Kubernetes version of the cluster",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"dry_run": schema.BoolAttribute{
+				MarkdownDescription: "Only report the manifests which would change without applying them",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"synced_manifests": schema.ListAttribute{
+				MarkdownDescription: "The manifests which were changed by the last sync, or would be changed in dry run mode",
+				Computed:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *kubernetesManifestsSyncResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+// ModifyPlan runs a dry run sync when a sync is planned and reports the manifest diffs as warnings.
+func (r *kubernetesManifestsSyncResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || !req.Plan.Raw.IsFullyKnown() || r.provider == nil {
+		return
+	}
+
+	var tfState, plan kubernetesManifestsSyncResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !req.State.Raw.IsNull() && tfState.Cluster.Equal(plan.Cluster) && tfState.DryRun.Equal(plan.DryRun) && maps.Equal(tfState.Triggers, plan.Triggers) {
+		return
+	}
+
+	diffs, err := r.sync(ctx, plan.Cluster.ValueString(), true)
+	if err != nil {
+		resp.Diagnostics.AddWarning(
+			"Kubernetes Manifests Dry Run Failed",
+			fmt.Sprintf("Failed to check the manifests of cluster '%s', they will be synced on apply: %s", plan.Cluster.ValueString(), err),
+		)
+		return
+	}
+
+	for _, diff := range diffs {
+		resp.Diagnostics.AddWarning(
+			fmt.Sprintf("Kubernetes Manifest Out of Sync: %s", diff.Path),
+			diff.Diff,
+		)
+	}
+}
+
+func (r *kubernetesManifestsSyncResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan kubernetesManifestsSyncResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diffs, err := r.sync(ctx, plan.Cluster.ValueString(), plan.DryRun.ValueBool())
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to sync the manifests of cluster '%s': %s", plan.Cluster.ValueString(), err))
+		return
+	}
+
+	plan.ID = plan.Cluster
+	plan.SyncedManifests = []types.String{}
+	for _, diff := range diffs {
+		plan.SyncedManifests = append(plan.SyncedManifests, types.StringValue(diff.Path))
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// Read keeps the state as is, the sync has no remote state.
+func (r *kubernetesManifestsSyncResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+// Update is never called with changes, as every input requires replacement.
+func (r *kubernetesManifestsSyncResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan kubernetesManifestsSyncResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *kubernetesManifestsSyncResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+// sync syncs the manifests of the cluster and returns the manifests which were changed.
+func (r *kubernetesManifestsSyncResource) sync(ctx context.Context, clusterName string, dryRun bool) ([]*management.KubernetesSyncManifestResponse, error) {
+	var changed []*management.KubernetesSyncManifestResponse
+
+	err := r.provider.management.KubernetesSyncManifests(ctx, clusterName, dryRun, func(msg *management.KubernetesSyncManifestResponse) error {
+		switch msg.ResponseType {
+		case management.KubernetesSyncManifestResponse_MANIFEST:
+			tflog.Info(ctx, "kubernetes manifest", map[string]any{
+				"cluster": clusterName,
+				"path":    msg.Path,
+				"skipped": msg.Skipped,
+				"dry_run": dryRun,
+			})
+
+			if !msg.Skipped {
+				changed = append(changed, msg)
+			}
+		case management.KubernetesSyncManifestResponse_ROLLOUT:
+			tflog.Info(ctx, "kubernetes manifest rollout", map[string]any{
+				"cluster": clusterName,
+				"path":    msg.Path,
+			})
+		case management.KubernetesSyncManifestResponse_UNKNOWN:
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
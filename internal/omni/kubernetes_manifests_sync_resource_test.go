@@ -0,0 +1,69 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesManifestsSyncResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_kubernetes_manifests_sync" "test" {
+  cluster = "talos-default"
+  dry_run = true
+  triggers = {
+    kubernetes_version = "1.32.3"
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_kubernetes_manifests_sync.test", "id", "talos-default"),
+					resource.TestCheckResourceAttrSet("omni_kubernetes_manifests_sync.test", "synced_manifests.#"),
+				),
+			},
+			{
+				Config: providerConfig + `
+resource "omni_kubernetes_manifests_sync" "test" {
+  cluster = "talos-default"
+  triggers = {
+    kubernetes_version = "1.32.3"
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_kubernetes_manifests_sync.test", "dry_run", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestKubernetesManifestsSync(t *testing.T) {
+	r := &kubernetesManifestsSyncResource{
+		provider: &omniProvider{
+			management: &fakeManagement{
+				kubernetesSyncManifests: func(clusterName string, dryRun bool) []*management.KubernetesSyncManifestResponse {
+					require.Equal(t, "talos-default", clusterName)
+					require.True(t, dryRun)
+
+					return []*management.KubernetesSyncManifestResponse{
+						{ResponseType: management.KubernetesSyncManifestResponse_MANIFEST, Path: "coredns", Diff: "-replicas: 1\n+replicas: 2"},
+						{ResponseType: management.KubernetesSyncManifestResponse_MANIFEST, Path: "kube-proxy", Skipped: true},
+						{ResponseType: management.KubernetesSyncManifestResponse_ROLLOUT, Path: "coredns"},
+					}
+				},
+			},
+		},
+	}
+
+	changed, err := r.sync(t.Context(), "talos-default", true)
+	require.NoError(t, err)
+	require.Len(t, changed, 1)
+	require.Equal(t, "coredns", changed[0].Path)
+}
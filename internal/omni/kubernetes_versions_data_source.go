@@ -0,0 +1,152 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var _ datasource.DataSource = &kubernetesVersionsDataSource{}
+
+func NewKubernetesVersionsDataSource() datasource.DataSource {
+	return &kubernetesVersionsDataSource{}
+}
+
+type kubernetesVersionsDataSource struct {
+	provider *omniProvider
+}
+
+type kubernetesVersionsDataSourceModel struct {
+	Constraint   types.String   `tfsdk:"constraint"`
+	TalosVersion types.String   `tfsdk:"talos_version"`
+	Versions     []types.String `tfsdk:"versions"`
+	Latest       types.String   `tfsdk:"latest"`
+}
+
+func (d *kubernetesVersionsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_kubernetes_versions"
+}
+
+func (d *kubernetesVersionsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "List the Kubernetes versions available in Omni",
+		Attributes: map[string]schema.Attribute{
+			"constraint": schema.StringAttribute{
+				MarkdownDescription: "Only list the versions matching this version constraint, e.g. '~> 1.32'",
+				Optional:            true,
+				Validators: []validator.String{
+					versionConstraintValidator{},
+				},
+			},
+			"talos_version": schema.StringAttribute{
+				MarkdownDescription: "Only list the versions supported by this Talos version",
+				Optional:            true,
+			},
+			"versions": schema.ListAttribute{
+				MarkdownDescription: "List of Kubernetes versions sorted from oldest to newest",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"latest": schema.StringAttribute{
+				MarkdownDescription: "The newest Kubernetes version matching the filters. Prereleases are only returned when the constraint includes a prerelease",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *kubernetesVersionsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *kubernetesVersionsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data kubernetesVersionsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	st := d.provider.state
+
+	kubernetesVersions, err := safe.StateList[*omni.KubernetesVersion](ctx, st, omni.NewKubernetesVersion(resources.DefaultNamespace, "").Metadata())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Kubernetes Versions",
+			fmt.Sprintf("Failed to get Kubernetes versions from Omni: %s", err),
+		)
+		return
+	}
+
+	var compatible []string
+	filterCompatible := !data.TalosVersion.IsNull()
+	if filterCompatible {
+		talosVersion, err := safe.StateGetByID[*omni.TalosVersion](ctx, st, strings.TrimPrefix(data.TalosVersion.ValueString(), "v"))
+		if err != nil {
+			if state.IsNotFoundError(err) {
+				resp.Diagnostics.AddError("Unknown Talos Version", fmt.Sprintf("Talos version '%s' is not available in Omni", data.TalosVersion.ValueString()))
+				return
+			}
+			resp.Diagnostics.AddError(
+				"Failed to Get Talos Version",
+				fmt.Sprintf("Failed to get Talos version from Omni: %s", err),
+			)
+			return
+		}
+
+		compatible = talosVersion.TypedSpec().Value.CompatibleKubernetesVersions
+	}
+
+	versions := make([]string, 0, kubernetesVersions.Len())
+	for item := range kubernetesVersions.All() {
+		v := item.TypedSpec().Value.Version
+		if filterCompatible && !slices.Contains(compatible, v) {
+			continue
+		}
+
+		versions = append(versions, v)
+	}
+
+	matching, err := matchingVersions(versions, data.Constraint.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid Version Constraint", err.Error())
+		return
+	}
+
+	data.Versions = []types.String{}
+	for _, v := range matching {
+		data.Versions = append(data.Versions, types.StringValue(v))
+	}
+
+	data.Latest = latestVersion(matching, data.Constraint.ValueString(), nil)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,27 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestKubernetesVersionsDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "omni_talos_versions" "all" {}
+
+data "omni_kubernetes_versions" "compatible" {
+  talos_version = data.omni_talos_versions.all.latest
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.omni_kubernetes_versions.compatible", "latest"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,75 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/cosi/labels"
+)
+
+var _ function.Function = &labelSelectorMatchesFunction{}
+
+func NewLabelSelectorMatchesFunction() function.Function {
+	return &labelSelectorMatchesFunction{}
+}
+
+type labelSelectorMatchesFunction struct{}
+
+func (f *labelSelectorMatchesFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "label_selector_matches"
+}
+
+func (f *labelSelectorMatchesFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Check whether labels match an Omni label selector",
+		MarkdownDescription: "Returns whether the labels, like the `labels` of a machine of the `omni_machines` data source, match " +
+			"the label selector the way Omni matches them. A machine class picks a machine when any of its `match_labels` matches. " +
+			"The function fails on invalid selectors.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "selector",
+				MarkdownDescription: "The label selector, e.g. `omni.sidero.dev/arch = amd64, !omni.sidero.dev/cluster`.",
+			},
+			function.MapParameter{
+				Name:                "labels",
+				MarkdownDescription: "The labels to match.",
+				ElementType:         types.StringType,
+				AllowNullValue:      true,
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *labelSelectorMatchesFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var (
+		selector string
+		values   map[string]string
+	)
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &selector, &values))
+	if resp.Error != nil {
+		return
+	}
+
+	query, err := labels.ParseQuery(selector)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Label selector %q is invalid: %v", selector, err))
+		return
+	}
+
+	var machineLabels resource.Labels
+
+	for key, value := range values {
+		machineLabels.Set(key, value)
+	}
+
+	resp.Error = resp.Result.Set(ctx, query.Matches(machineLabels))
+}
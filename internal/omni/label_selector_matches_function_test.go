@@ -0,0 +1,63 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelSelectorMatchesFunction(t *testing.T) {
+	machineLabels := map[string]string{
+		"omni.sidero.dev/arch":  "amd64",
+		"omni.sidero.dev/cores": "8",
+		"zone":                  "a",
+	}
+
+	tests := []struct {
+		selector string
+		labels   map[string]string
+		matches  bool
+	}{
+		{selector: "omni.sidero.dev/arch = amd64", labels: machineLabels, matches: true},
+		{selector: "omni.sidero.dev/arch = amd64, zone = b", labels: machineLabels, matches: false},
+		{selector: "zone in (a, b), !omni.sidero.dev/cluster", labels: machineLabels, matches: true},
+		{selector: "omni.sidero.dev/cores >= 16", labels: machineLabels, matches: false},
+		{selector: "omni.sidero.dev/cores > 4", labels: machineLabels, matches: true},
+		{selector: "zone", labels: nil, matches: false},
+		{selector: "!zone", labels: nil, matches: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			labelsValue := types.MapNull(types.StringType)
+			if tt.labels != nil {
+				var diags diag.Diagnostics
+
+				labelsValue, diags = types.MapValueFrom(t.Context(), types.StringType, tt.labels)
+				require.False(t, diags.HasError())
+			}
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.BoolUnknown())}
+
+			(&labelSelectorMatchesFunction{}).Run(t.Context(), function.RunRequest{
+				Arguments: function.NewArgumentsData([]attr.Value{types.StringValue(tt.selector), labelsValue}),
+			}, resp)
+			require.Nil(t, resp.Error)
+			require.Equal(t, types.BoolValue(tt.matches), resp.Result.Value())
+		})
+	}
+}
+
+func TestLabelSelectorMatchesFunctionInvalid(t *testing.T) {
+	resp := &function.RunResponse{Result: function.NewResultData(types.BoolUnknown())}
+
+	(&labelSelectorMatchesFunction{}).Run(t.Context(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("a in b"), types.MapNull(types.StringType)}),
+	}, resp)
+	require.NotNil(t, resp.Error)
+	require.Equal(t, int64(0), *resp.Error.FunctionArgument)
+}
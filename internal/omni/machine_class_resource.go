@@ -0,0 +1,427 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/cosi/labels"
+	"github.com/siderolabs/omni/client/pkg/meta"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var (
+	_ resource.Resource                     = &machineClassResource{}
+	_ resource.ResourceWithConfigValidators = &machineClassResource{}
+	_ resource.ResourceWithImportState      = &machineClassResource{}
+)
+
+func NewMachineClassResource() resource.Resource {
+	return &machineClassResource{}
+}
+
+type machineClassResource struct {
+	provider *omniProvider
+}
+
+type machineClassResourceModel struct {
+	ID            types.String               `tfsdk:"id"`
+	Name          types.String               `tfsdk:"name"`
+	MatchLabels   []types.String             `tfsdk:"match_labels"`
+	AutoProvision *machineClassAutoProvision `tfsdk:"auto_provision"`
+}
+
+type machineClassAutoProvision struct {
+	ProviderID   types.String      `tfsdk:"provider_id"`
+	KernelArgs   []types.String    `tfsdk:"kernel_args"`
+	MetaValues   map[string]string `tfsdk:"meta_values"`
+	GRPCTunnel   types.Bool        `tfsdk:"grpc_tunnel"`
+	ProviderData types.String      `tfsdk:"provider_data"`
+}
+
+func (r *machineClassResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_machine_class"
+}
+
+func (r *machineClassResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages an Omni machine class, selecting machines by labels or provisioning them automatically through an infra provider.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The name of the machine class",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of the machine class",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"match_labels": schema.ListAttribute{
+				MarkdownDescription: "Label selectors matching the machines of the class, e.g. `omni.sidero.dev/arch = amd64, rack in (r1, r2)`. " +
+					"A machine matches if it matches any of the selectors",
+				Optional:    true,
+				ElementType: types.StringType,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.ValueStringsAre(labelSelectorValidator{}),
+				},
+			},
+			"auto_provision": schema.SingleNestedAttribute{
+				MarkdownDescription: "Provision the machines of the class automatically through an infra provider",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"provider_id": schema.StringAttribute{
+						MarkdownDescription: "The ID of the infra provider",
+						Required:            true,
+					},
+					"kernel_args": schema.ListAttribute{
+						MarkdownDescription: "Extra kernel arguments of the provisioned machines",
+						Optional:            true,
+						ElementType:         types.StringType,
+					},
+					"meta_values": schema.MapAttribute{
+						MarkdownDescription: "META values of the provisioned machines, keyed by the META key number",
+						Optional:            true,
+						ElementType:         types.StringType,
+						Validators: []validator.Map{
+							metaValuesValidator{},
+						},
+					},
+					"grpc_tunnel": schema.BoolAttribute{
+						MarkdownDescription: "Use SideroLink GRPC tunnel. If not set, the Omni default is used",
+						Optional:            true,
+					},
+					"provider_data": schema.StringAttribute{
+						MarkdownDescription: "Provider specific data in YAML",
+						Optional:            true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *machineClassResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("match_labels"),
+			path.MatchRoot("auto_provision"),
+		),
+	}
+}
+
+func (r *machineClassResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *machineClassResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	st := r.provider.state
+
+	var plan machineClassResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	spec, err := plan.toSpec()
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	machineClass := omni.NewMachineClass(resources.DefaultNamespace, plan.Name.ValueString())
+	machineClass.TypedSpec().Value = spec
+
+	if err := st.Create(ctx, machineClass); err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to create machine class '%s': %v", plan.Name.ValueString(), err))
+		return
+	}
+
+	plan.ID = plan.Name
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *machineClassResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	st := r.provider.state
+
+	var tfState machineClassResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	machineClass, err := safe.StateGetByID[*omni.MachineClass](ctx, st, tfState.ID.ValueString())
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read machine class '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	model := machineClassModelFromSpec(machineClass.Metadata().ID(), machineClass.TypedSpec().Value)
+	model.keepEmptyCollections(tfState)
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (r *machineClassResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	st := r.provider.state
+
+	var plan machineClassResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	spec, err := plan.toSpec()
+	if err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, err.Error())
+		return
+	}
+
+	md := omni.NewMachineClass(resources.DefaultNamespace, plan.Name.ValueString()).Metadata()
+
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, md, func(machineClass *omni.MachineClass) error {
+		machineClass.TypedSpec().Value = spec
+
+		return nil
+	}); err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, fmt.Sprintf("Failed to update machine class '%s': %v", plan.Name.ValueString(), err))
+		return
+	}
+
+	plan.ID = plan.Name
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *machineClassResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	st := r.provider.state
+
+	var tfState machineClassResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := teardownAndDestroy(ctx, st, omni.NewMachineClass(resources.DefaultNamespace, tfState.ID.ValueString()).Metadata()); err != nil {
+		resp.Diagnostics.AddError(errDeleteFailed, err.Error())
+	}
+}
+
+func (r *machineClassResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (m machineClassResourceModel) toSpec() (*specs.MachineClassSpec, error) {
+	spec := &specs.MachineClassSpec{
+		MatchLabels: stringValues(m.MatchLabels),
+	}
+
+	if m.AutoProvision == nil {
+		return spec, nil
+	}
+
+	metaValues, err := parseMetaValues(m.AutoProvision.MetaValues)
+	if err != nil {
+		return nil, err
+	}
+
+	spec.AutoProvision = &specs.MachineClassSpec_Provision{
+		ProviderId:   m.AutoProvision.ProviderID.ValueString(),
+		KernelArgs:   stringValues(m.AutoProvision.KernelArgs),
+		ProviderData: m.AutoProvision.ProviderData.ValueString(),
+		GrpcTunnel:   specs.GrpcTunnelMode_UNSET,
+	}
+
+	if !m.AutoProvision.GRPCTunnel.IsNull() {
+		spec.AutoProvision.GrpcTunnel = specs.GrpcTunnelMode_DISABLED
+		if m.AutoProvision.GRPCTunnel.ValueBool() {
+			spec.AutoProvision.GrpcTunnel = specs.GrpcTunnelMode_ENABLED
+		}
+	}
+
+	for _, key := range sortedMetaKeys(metaValues) {
+		spec.AutoProvision.MetaValues = append(spec.AutoProvision.MetaValues, &specs.MetaValue{
+			Key:   key,
+			Value: metaValues[key],
+		})
+	}
+
+	return spec, nil
+}
+
+func machineClassModelFromSpec(id string, spec *specs.MachineClassSpec) machineClassResourceModel {
+	model := machineClassResourceModel{
+		ID:          types.StringValue(id),
+		Name:        types.StringValue(id),
+		MatchLabels: stringList(spec.GetMatchLabels()),
+	}
+
+	provision := spec.GetAutoProvision()
+	if provision == nil {
+		return model
+	}
+
+	model.AutoProvision = &machineClassAutoProvision{
+		ProviderID:   types.StringValue(provision.GetProviderId()),
+		KernelArgs:   stringList(provision.GetKernelArgs()),
+		ProviderData: optionalStringValue(provision.GetProviderData()),
+		GRPCTunnel:   types.BoolNull(),
+	}
+
+	switch provision.GetGrpcTunnel() {
+	case specs.GrpcTunnelMode_ENABLED:
+		model.AutoProvision.GRPCTunnel = types.BoolValue(true)
+	case specs.GrpcTunnelMode_DISABLED:
+		model.AutoProvision.GRPCTunnel = types.BoolValue(false)
+	case specs.GrpcTunnelMode_UNSET:
+	}
+
+	if len(provision.GetMetaValues()) > 0 {
+		model.AutoProvision.MetaValues = map[string]string{}
+	}
+
+	for _, metaValue := range provision.GetMetaValues() {
+		model.AutoProvision.MetaValues[strconv.FormatUint(uint64(metaValue.GetKey()), 10)] = metaValue.GetValue()
+	}
+
+	return model
+}
+
+// keepEmptyCollections keeps the empty lists and maps of the prior model, Omni stores them like unset ones.
+func (m *machineClassResourceModel) keepEmptyCollections(prior machineClassResourceModel) {
+	if m.AutoProvision == nil || prior.AutoProvision == nil {
+		return
+	}
+
+	if m.AutoProvision.KernelArgs == nil && prior.AutoProvision.KernelArgs != nil {
+		m.AutoProvision.KernelArgs = []types.String{}
+	}
+
+	if m.AutoProvision.MetaValues == nil && prior.AutoProvision.MetaValues != nil {
+		m.AutoProvision.MetaValues = map[string]string{}
+	}
+}
+
+// Helper functions
+
+// parseMetaValues converts META values keyed by the decimal META key into the form Omni expects.
+func parseMetaValues(values map[string]string) (map[uint32]string, error) {
+	result := make(map[uint32]string, len(values))
+
+	for key, value := range values {
+		parsed, err := strconv.ParseUint(key, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid META key %q: must be a number between 0 and 255", key)
+		}
+
+		// Omni stores the key as a number, e.g. "07" would be read back as "7"
+		if canonical := strconv.FormatUint(parsed, 10); canonical != key {
+			return nil, fmt.Errorf("invalid META key %q: must be written as %q", key, canonical)
+		}
+
+		if !meta.CanSetMetaKey(int(parsed)) {
+			return nil, fmt.Errorf("META key %d is reserved and can not be set", parsed)
+		}
+
+		result[uint32(parsed)] = value
+	}
+
+	return result, nil
+}
+
+func sortedMetaKeys(values map[uint32]string) []uint32 {
+	keys := make([]uint32, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
+
+// labelSelectorValidator checks that a string is a valid Omni label selector.
+type labelSelectorValidator struct{}
+
+func (v labelSelectorValidator) Description(_ context.Context) string {
+	return "value must be a valid label selector, e.g. 'key = value, other in (a, b), !excluded'"
+}
+
+func (v labelSelectorValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v labelSelectorValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := labels.ParseQuery(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Label Selector",
+			fmt.Sprintf("Label selector %q is invalid: %v", req.ConfigValue.ValueString(), err),
+		)
+	}
+}
+
+// metaValuesValidator checks that META values are keyed by a META key which can be set by users.
+type metaValuesValidator struct{}
+
+func (v metaValuesValidator) Description(_ context.Context) string {
+	return "keys must be META key numbers which are not reserved by Talos or Omni"
+}
+
+func (v metaValuesValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v metaValuesValidator) ValidateMap(ctx context.Context, req validator.MapRequest, resp *validator.MapResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for key := range req.ConfigValue.Elements() {
+		if _, err := parseMetaValues(map[string]string{key: ""}); err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path.AtMapKey(key), "Invalid META Key", err.Error())
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachineClassResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_machine_class" "test" {
+  name         = "test-machine-class"
+  match_labels = ["omni.sidero.dev/platform = test-machine-class"]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_machine_class.test", "id", "test-machine-class"),
+					resource.TestCheckResourceAttr("omni_machine_class.test", "match_labels.0", "omni.sidero.dev/platform = test-machine-class"),
+				),
+			},
+			{
+				Config: providerConfig + `
+resource "omni_machine_class" "test" {
+  name         = "test-machine-class"
+  match_labels = ["omni.sidero.dev/arch = amd64, rack in (r1, r2)", "zone = eu-1a"]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_machine_class.test", "match_labels.#", "2"),
+				),
+			},
+			{
+				ResourceName:      "omni_machine_class.test",
+				ImportState:       true,
+				ImportStateId:     "test-machine-class",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestMachineClassModelRoundTrip(t *testing.T) {
+	model := machineClassResourceModel{
+		ID:   types.StringValue("aws"),
+		Name: types.StringValue("aws"),
+		AutoProvision: &machineClassAutoProvision{
+			ProviderID:   types.StringValue("aws"),
+			KernelArgs:   []types.String{types.StringValue("console=ttyS0")},
+			MetaValues:   map[string]string{"10": "network", "13": "custom"},
+			GRPCTunnel:   types.BoolValue(true),
+			ProviderData: types.StringValue("size: t3.large\n"),
+		},
+	}
+
+	spec, err := model.toSpec()
+	require.NoError(t, err)
+	require.Equal(t, model, machineClassModelFromSpec("aws", spec))
+}
+
+func TestParseMetaValues(t *testing.T) {
+	values, err := parseMetaValues(map[string]string{"10": "network"})
+	require.NoError(t, err)
+	require.Equal(t, map[uint32]string{10: "network"}, values)
+
+	_, err = parseMetaValues(map[string]string{"network": "x"})
+	require.ErrorContains(t, err, "invalid META key")
+
+	_, err = parseMetaValues(map[string]string{"6": "upgrade"})
+	require.ErrorContains(t, err, "reserved")
+
+	_, err = parseMetaValues(map[string]string{"013": "custom"})
+	require.ErrorContains(t, err, `must be written as "13"`)
+}
+
+func TestMachineClassModelKeepsEmptyCollections(t *testing.T) {
+	model := machineClassResourceModel{
+		ID:   types.StringValue("aws"),
+		Name: types.StringValue("aws"),
+		AutoProvision: &machineClassAutoProvision{
+			ProviderID:   types.StringValue("aws"),
+			KernelArgs:   []types.String{},
+			MetaValues:   map[string]string{},
+			GRPCTunnel:   types.BoolNull(),
+			ProviderData: types.StringNull(),
+		},
+	}
+
+	spec, err := model.toSpec()
+	require.NoError(t, err)
+
+	read := machineClassModelFromSpec("aws", spec)
+	read.keepEmptyCollections(model)
+	require.Equal(t, model, read)
+
+	// unset collections stay unset
+	model.AutoProvision.KernelArgs, model.AutoProvision.MetaValues = nil, nil
+
+	read = machineClassModelFromSpec("aws", spec)
+	read.keepEmptyCollections(model)
+	require.Equal(t, model, read)
+}
@@ -0,0 +1,268 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var (
+	_ resource.Resource                = &machineLabelsResource{}
+	_ resource.ResourceWithImportState = &machineLabelsResource{}
+)
+
+func NewMachineLabelsResource() resource.Resource {
+	return &machineLabelsResource{}
+}
+
+type machineLabelsResource struct {
+	provider *omniProvider
+}
+
+type machineLabelsResourceModel struct {
+	ID        types.String      `tfsdk:"id"`
+	MachineID types.String      `tfsdk:"machine_id"`
+	Labels    map[string]string `tfsdk:"labels"`
+}
+
+func (r *machineLabelsResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_machine_labels"
+}
+
+func (r *machineLabelsResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages user defined labels of a machine. " +
+			"Only the label keys set in this resource are managed, labels added by other means are left untouched.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the machine",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"machine_id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the machine to label",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"labels": schema.MapAttribute{
+				MarkdownDescription: "The labels to set on the machine. System labels prefixed with `" + omni.SystemLabelPrefix + "` are not allowed",
+				Required:            true,
+				ElementType:         types.StringType,
+				Validators: []validator.Map{
+					userLabelsValidator{},
+				},
+			},
+		},
+	}
+}
+
+func (r *machineLabelsResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *machineLabelsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan machineLabelsResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.reconcileLabels(ctx, plan.MachineID.ValueString(), nil, plan.Labels); err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	plan.ID = plan.MachineID
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *machineLabelsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	st := r.provider.state
+
+	var tfState machineLabelsResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	machineLabels, err := safe.StateGetByID[*omni.MachineLabels](ctx, st, tfState.ID.ValueString())
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read labels of machine '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	// On import every user label is owned, otherwise only the ones already in the state
+	owned := tfState.Labels
+	if owned == nil {
+		owned = machineLabels.Metadata().Labels().Raw()
+	}
+
+	labels := map[string]string{}
+	for key := range owned {
+		if strings.HasPrefix(key, omni.SystemLabelPrefix) {
+			continue
+		}
+
+		if value, ok := machineLabels.Metadata().Labels().Get(key); ok {
+			labels[key] = value
+		}
+	}
+
+	tfState.MachineID = tfState.ID
+	tfState.Labels = labels
+	resp.Diagnostics.Append(resp.State.Set(ctx, tfState)...)
+}
+
+func (r *machineLabelsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var tfState, plan machineLabelsResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.reconcileLabels(ctx, plan.MachineID.ValueString(), tfState.Labels, plan.Labels); err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, err.Error())
+		return
+	}
+
+	plan.ID = plan.MachineID
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *machineLabelsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var tfState machineLabelsResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.reconcileLabels(ctx, tfState.ID.ValueString(), tfState.Labels, nil); err != nil {
+		resp.Diagnostics.AddError(errDeleteFailed, err.Error())
+	}
+}
+
+func (r *machineLabelsResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// reconcileLabels removes the previously owned labels which are no longer desired and sets the desired ones,
+// creating the MachineLabels resource if needed and destroying it once it has no labels left.
+func (r *machineLabelsResource) reconcileLabels(ctx context.Context, machineID string, owned, desired map[string]string) error {
+	st := r.provider.state
+
+	machineLabels := omni.NewMachineLabels(resources.DefaultNamespace, machineID)
+
+	_, err := st.Get(ctx, machineLabels.Metadata())
+	if err != nil {
+		if !state.IsNotFoundError(err) {
+			return fmt.Errorf("failed to check labels of machine '%s': %v", machineID, err)
+		}
+
+		if len(desired) == 0 {
+			return nil
+		}
+
+		applyLabels(machineLabels, owned, desired)
+
+		if err := st.Create(ctx, machineLabels); err != nil {
+			return fmt.Errorf("failed to create labels of machine '%s': %v", machineID, err)
+		}
+
+		return nil
+	}
+
+	updated, err := safe.StateUpdateWithConflicts(ctx, st, machineLabels.Metadata(), func(res *omni.MachineLabels) error {
+		applyLabels(res, owned, desired)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update labels of machine '%s': %v", machineID, err)
+	}
+
+	if updated.Metadata().Labels().Empty() {
+		if err := st.Destroy(ctx, updated.Metadata()); err != nil && !state.IsNotFoundError(err) {
+			return fmt.Errorf("failed to delete labels of machine '%s': %v", machineID, err)
+		}
+	}
+
+	return nil
+}
+
+// Helper functions
+func applyLabels(machineLabels *omni.MachineLabels, owned, desired map[string]string) {
+	for key := range owned {
+		if _, ok := desired[key]; !ok {
+			machineLabels.Metadata().Labels().Delete(key)
+		}
+	}
+
+	for key, value := range desired {
+		machineLabels.Metadata().Labels().Set(key, value)
+	}
+}
+
+// userLabelsValidator rejects empty label keys and keys using the Omni system label prefix.
+type userLabelsValidator struct{}
+
+func (v userLabelsValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("label keys must not be empty or start with '%s'", omni.SystemLabelPrefix)
+}
+
+func (v userLabelsValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v userLabelsValidator) ValidateMap(ctx context.Context, req validator.MapRequest, resp *validator.MapResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for key := range req.ConfigValue.Elements() {
+		if key == "" || strings.HasPrefix(key, omni.SystemLabelPrefix) {
+			resp.Diagnostics.AddAttributeError(
+				req.Path.AtMapKey(key),
+				"Invalid Label",
+				fmt.Sprintf("Label %q is not allowed: %s", key, v.Description(ctx)),
+			)
+		}
+	}
+}
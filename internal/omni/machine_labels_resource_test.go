@@ -0,0 +1,63 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachineLabelsResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "omni_machines" "all" {}
+
+resource "omni_machine_labels" "test" {
+  machine_id = data.omni_machines.all.machines[0].id
+  labels = {
+    rack = "r1"
+    zone = "eu-1a"
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_machine_labels.test", "labels.rack", "r1"),
+					resource.TestCheckResourceAttr("omni_machine_labels.test", "labels.zone", "eu-1a"),
+				),
+			},
+			{
+				// Drop a label
+				Config: providerConfig + `
+data "omni_machines" "all" {}
+
+resource "omni_machine_labels" "test" {
+  machine_id = data.omni_machines.all.machines[0].id
+  labels = {
+    rack = "r2"
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_machine_labels.test", "labels.%", "1"),
+					resource.TestCheckResourceAttr("omni_machine_labels.test", "labels.rack", "r2"),
+				),
+			},
+		},
+	})
+}
+
+func TestApplyLabels(t *testing.T) {
+	machineLabels := omni.NewMachineLabels(resources.DefaultNamespace, "machine")
+	machineLabels.Metadata().Labels().Set("owner", "someone-else")
+	machineLabels.Metadata().Labels().Set("rack", "r1")
+	machineLabels.Metadata().Labels().Set("zone", "eu-1a")
+
+	applyLabels(machineLabels, map[string]string{"rack": "r1", "zone": "eu-1a"}, map[string]string{"rack": "r2"})
+
+	require.Equal(t, map[string]string{"owner": "someone-else", "rack": "r2"}, machineLabels.Metadata().Labels().Raw())
+}
@@ -0,0 +1,505 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+const (
+	machineSetRoleControlPlane = "control-plane"
+	machineSetRoleWorkers      = "workers"
+)
+
+var (
+	_ resource.Resource                     = &machineSetResource{}
+	_ resource.ResourceWithConfigValidators = &machineSetResource{}
+	_ resource.ResourceWithValidateConfig   = &machineSetResource{}
+	_ resource.ResourceWithModifyPlan       = &machineSetResource{}
+	_ resource.ResourceWithImportState      = &machineSetResource{}
+)
+
+func NewMachineSetResource() resource.Resource {
+	return &machineSetResource{}
+}
+
+type machineSetResource struct {
+	provider *omniProvider
+}
+
+type machineSetResourceModel struct {
+	ID            types.String             `tfsdk:"id"`
+	Cluster       types.String             `tfsdk:"cluster"`
+	Role          types.String             `tfsdk:"role"`
+	Name          types.String             `tfsdk:"name"`
+	MachineClass  machineSetMachineClass   `tfsdk:"machine_class"`
+	BootstrapSpec *machineSetBootstrapSpec `tfsdk:"bootstrap_spec"`
+}
+
+type machineSetMachineClass struct {
+	Name         types.String `tfsdk:"name"`
+	MachineCount types.Int64  `tfsdk:"machine_count"`
+	Unlimited    types.Bool   `tfsdk:"unlimited"`
+}
+
+type machineSetBootstrapSpec struct {
+	SourceCluster types.String `tfsdk:"source_cluster"`
+	ClusterUUID   types.String `tfsdk:"cluster_uuid"`
+	Snapshot      types.String `tfsdk:"snapshot"`
+}
+
+func (r *machineSetResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_machine_set"
+}
+
+func (r *machineSetResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages a machine set of a cluster, allocating its machines from a machine class. " +
+			"A control plane machine set can be bootstrapped from an etcd backup to restore a cluster.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the machine set, e.g. `<cluster>-control-planes` or `<cluster>-workers`",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"cluster": schema.StringAttribute{
+				MarkdownDescription: "The name of the cluster",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"role": schema.StringAttribute{
+				MarkdownDescription: "The role of the machines, either '" + machineSetRoleControlPlane + "' or '" + machineSetRoleWorkers + "'",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(machineSetRoleControlPlane, machineSetRoleWorkers),
+				},
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of an additional worker machine set. If not set, the default machine set of the role is managed",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"machine_class": schema.SingleNestedAttribute{
+				MarkdownDescription: "The machine class to allocate the machines from",
+				Required:            true,
+				Attributes: map[string]schema.Attribute{
+					"name": schema.StringAttribute{
+						MarkdownDescription: "The name of the machine class",
+						Required:            true,
+					},
+					"machine_count": schema.Int64Attribute{
+						MarkdownDescription: "The number of machines to allocate",
+						Optional:            true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"unlimited": schema.BoolAttribute{
+						MarkdownDescription: "Allocate all the available machines of the class",
+						Optional:            true,
+					},
+				},
+			},
+			"bootstrap_spec": schema.SingleNestedAttribute{
+				MarkdownDescription: "Restore the cluster from an etcd backup when the control plane machine set is created. " +
+					"The backup must exist in the etcd backups of the source cluster, see the `omni_etcd_backups` data source",
+				Optional: true,
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.RequiresReplaceIf(bootstrapSpecRequiresReplace,
+						"Changing the backup to bootstrap from requires a replacement",
+						"Changing the backup to bootstrap from requires a replacement"),
+				},
+				Attributes: map[string]schema.Attribute{
+					"source_cluster": schema.StringAttribute{
+						MarkdownDescription: "The name of the cluster the backup was taken from. It is not stored in Omni, after an import it is looked up by `cluster_uuid`. " +
+							"Changing it alone does not replace the machine set",
+						Required: true,
+					},
+					"cluster_uuid": schema.StringAttribute{
+						MarkdownDescription: "The UUID of the cluster the backup was taken from, shown by `omnictl get clusteruuid <cluster>`",
+						Required:            true,
+					},
+					"snapshot": schema.StringAttribute{
+						MarkdownDescription: "The snapshot name of the backup",
+						Required:            true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *machineSetResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("machine_class").AtName("machine_count"),
+			path.MatchRoot("machine_class").AtName("unlimited"),
+		),
+	}
+}
+
+func (r *machineSetResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var role, name types.String
+	var bootstrapSpec types.Object
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("role"), &role)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("name"), &name)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("bootstrap_spec"), &bootstrapSpec)...)
+	if resp.Diagnostics.HasError() || role.IsUnknown() {
+		return
+	}
+
+	if role.ValueString() != machineSetRoleControlPlane {
+		if !bootstrapSpec.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("bootstrap_spec"), errValidationFailed, "Only a control plane machine set can be bootstrapped from an etcd backup")
+		}
+		return
+	}
+
+	if !name.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), errValidationFailed, "A cluster has a single control plane machine set, the name can only be set for workers")
+	}
+}
+
+func (r *machineSetResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+// ModifyPlan checks that the etcd backup exists before a machine set is bootstrapped from it.
+func (r *machineSetResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// The bootstrap spec is only used at creation time
+	if req.Plan.Raw.IsNull() || !req.State.Raw.IsNull() || !req.Plan.Raw.IsFullyKnown() || r.provider == nil {
+		return
+	}
+
+	var plan machineSetResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() || plan.BootstrapSpec == nil {
+		return
+	}
+
+	if err := validateBootstrapSpec(ctx, r.provider.state, plan.BootstrapSpec); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("bootstrap_spec"), errValidationFailed, err.Error())
+	}
+}
+
+func (r *machineSetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	st := r.provider.state
+
+	var plan machineSetResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	machineSet := plan.toMachineSet()
+
+	if err := st.Create(ctx, machineSet); err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to create machine set '%s': %v", machineSet.Metadata().ID(), err))
+		return
+	}
+
+	plan.ID = types.StringValue(machineSet.Metadata().ID())
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *machineSetResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	st := r.provider.state
+
+	var tfState machineSetResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	machineSet, err := safe.StateGetByID[*omni.MachineSet](ctx, st, tfState.ID.ValueString())
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read machine set '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	model := machineSetModelFromResource(machineSet)
+
+	// The source cluster is not stored in Omni, after an import it is looked up by the cluster UUID
+	if model.BootstrapSpec != nil {
+		if tfState.BootstrapSpec != nil && !tfState.BootstrapSpec.SourceCluster.IsNull() {
+			model.BootstrapSpec.SourceCluster = tfState.BootstrapSpec.SourceCluster
+		} else {
+			sourceCluster, err := sourceClusterByUUID(ctx, st, model.BootstrapSpec.ClusterUUID.ValueString(), model.Cluster.ValueString())
+			if err != nil {
+				resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read the source cluster of machine set '%s': %v", machineSet.Metadata().ID(), err))
+				return
+			}
+
+			model.BootstrapSpec.SourceCluster = sourceCluster
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (r *machineSetResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	st := r.provider.state
+
+	var plan machineSetResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	md := omni.NewMachineSet(resources.DefaultNamespace, plan.machineSetID()).Metadata()
+
+	// The bootstrap spec is immutable, only the machine allocation can change
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, md, func(machineSet *omni.MachineSet) error {
+		machineSet.TypedSpec().Value.MachineClass = nil //nolint:staticcheck
+		machineSet.TypedSpec().Value.MachineAllocation = plan.MachineClass.toAllocation()
+
+		return nil
+	}); err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, fmt.Sprintf("Failed to update machine set '%s': %v", md.ID(), err))
+		return
+	}
+
+	plan.ID = types.StringValue(md.ID())
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *machineSetResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	st := r.provider.state
+
+	var tfState machineSetResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := teardownAndDestroy(ctx, st, omni.NewMachineSet(resources.DefaultNamespace, tfState.ID.ValueString()).Metadata()); err != nil {
+		resp.Diagnostics.AddError(errDeleteFailed, err.Error())
+	}
+}
+
+func (r *machineSetResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (m machineSetResourceModel) machineSetID() string {
+	clusterName := m.Cluster.ValueString()
+
+	switch {
+	case m.Role.ValueString() == machineSetRoleControlPlane:
+		return omni.ControlPlanesResourceID(clusterName)
+	case !m.Name.IsNull():
+		return omni.AdditionalWorkersResourceID(clusterName, m.Name.ValueString())
+	default:
+		return omni.WorkersResourceID(clusterName)
+	}
+}
+
+func (m machineSetResourceModel) toMachineSet() *omni.MachineSet {
+	machineSet := omni.NewMachineSet(resources.DefaultNamespace, m.machineSetID())
+
+	machineSet.Metadata().Labels().Set(omni.LabelCluster, m.Cluster.ValueString())
+
+	if m.Role.ValueString() == machineSetRoleControlPlane {
+		machineSet.Metadata().Labels().Set(omni.LabelControlPlaneRole, "")
+	} else {
+		machineSet.Metadata().Labels().Set(omni.LabelWorkerRole, "")
+	}
+
+	spec := machineSet.TypedSpec().Value
+	spec.UpdateStrategy = specs.MachineSetSpec_Rolling
+	spec.MachineAllocation = m.MachineClass.toAllocation()
+
+	if m.BootstrapSpec != nil {
+		spec.BootstrapSpec = &specs.MachineSetSpec_BootstrapSpec{
+			ClusterUuid: m.BootstrapSpec.ClusterUUID.ValueString(),
+			Snapshot:    m.BootstrapSpec.Snapshot.ValueString(),
+		}
+	}
+
+	return machineSet
+}
+
+func (m machineSetMachineClass) toAllocation() *specs.MachineSetSpec_MachineAllocation {
+	allocation := &specs.MachineSetSpec_MachineAllocation{
+		Name:   m.Name.ValueString(),
+		Source: specs.MachineSetSpec_MachineAllocation_MachineClass,
+	}
+
+	if m.Unlimited.ValueBool() {
+		allocation.AllocationType = specs.MachineSetSpec_MachineAllocation_Unlimited
+	} else {
+		allocation.AllocationType = specs.MachineSetSpec_MachineAllocation_Static
+		allocation.MachineCount = uint32(m.MachineCount.ValueInt64())
+	}
+
+	return allocation
+}
+
+func machineSetModelFromResource(machineSet *omni.MachineSet) machineSetResourceModel {
+	clusterName, _ := machineSet.Metadata().Labels().Get(omni.LabelCluster)
+
+	model := machineSetResourceModel{
+		ID:      types.StringValue(machineSet.Metadata().ID()),
+		Cluster: types.StringValue(clusterName),
+		Role:    types.StringValue(machineSetRoleWorkers),
+		Name:    types.StringNull(),
+		MachineClass: machineSetMachineClass{
+			MachineCount: types.Int64Null(),
+			Unlimited:    types.BoolNull(),
+		},
+	}
+
+	switch {
+	case machineSet.Metadata().ID() == omni.ControlPlanesResourceID(clusterName):
+		model.Role = types.StringValue(machineSetRoleControlPlane)
+	case machineSet.Metadata().ID() != omni.WorkersResourceID(clusterName):
+		model.Name = types.StringValue(strings.TrimPrefix(machineSet.Metadata().ID(), clusterName+"-"))
+	}
+
+	if allocation := omni.GetMachineAllocation(machineSet); allocation != nil {
+		model.MachineClass.Name = types.StringValue(allocation.Name)
+
+		if allocation.AllocationType == specs.MachineSetSpec_MachineAllocation_Unlimited {
+			model.MachineClass.Unlimited = types.BoolValue(true)
+		} else {
+			model.MachineClass.MachineCount = types.Int64Value(int64(allocation.MachineCount))
+		}
+	}
+
+	if bootstrapSpec := machineSet.TypedSpec().Value.GetBootstrapSpec(); bootstrapSpec != nil {
+		model.BootstrapSpec = &machineSetBootstrapSpec{
+			SourceCluster: types.StringNull(),
+			ClusterUUID:   types.StringValue(bootstrapSpec.ClusterUuid),
+			Snapshot:      types.StringValue(bootstrapSpec.Snapshot),
+		}
+	}
+
+	return model
+}
+
+// Helper functions
+
+// bootstrapSpecRequiresReplace replaces the machine set when the backup to bootstrap from changes.
+// The source cluster is left out, it is not stored in Omni and may be unknown after an import.
+func bootstrapSpecRequiresReplace(ctx context.Context, req planmodifier.ObjectRequest, resp *objectplanmodifier.RequiresReplaceIfFuncResponse) {
+	if req.StateValue.IsNull() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() {
+		resp.RequiresReplace = true
+		return
+	}
+
+	var stateSpec, planSpec machineSetBootstrapSpec
+	resp.Diagnostics.Append(req.StateValue.As(ctx, &stateSpec, basetypes.ObjectAsOptions{})...)
+	resp.Diagnostics.Append(req.PlanValue.As(ctx, &planSpec, basetypes.ObjectAsOptions{})...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.RequiresReplace = !stateSpec.ClusterUUID.Equal(planSpec.ClusterUUID) || !stateSpec.Snapshot.Equal(planSpec.Snapshot)
+}
+
+// sourceClusterByUUID returns the name of the cluster with the UUID, other than the restored cluster itself,
+// or null if the source cluster does not exist anymore.
+func sourceClusterByUUID(ctx context.Context, st state.State, uuid, cluster string) (types.String, error) {
+	clusterUUIDs, err := safe.StateListAll[*omni.ClusterUUID](ctx, st)
+	if err != nil {
+		return types.StringNull(), err
+	}
+
+	for clusterUUID := range clusterUUIDs.All() {
+		if clusterUUID.TypedSpec().Value.Uuid == uuid && clusterUUID.Metadata().ID() != cluster {
+			return types.StringValue(clusterUUID.Metadata().ID()), nil
+		}
+	}
+
+	return types.StringNull(), nil
+}
+
+// validateBootstrapSpec checks that the snapshot exists in the etcd backups of the source cluster
+// and that the cluster UUID belongs to the source cluster when it still exists.
+func validateBootstrapSpec(ctx context.Context, st state.State, bootstrapSpec *machineSetBootstrapSpec) error {
+	sourceCluster := bootstrapSpec.SourceCluster.ValueString()
+
+	clusterUUID, err := safe.StateGetByID[*omni.ClusterUUID](ctx, st, sourceCluster)
+	if err != nil && !state.IsNotFoundError(err) {
+		return fmt.Errorf("failed to read the UUID of cluster '%s': %v", sourceCluster, err)
+	}
+
+	if err == nil && clusterUUID.TypedSpec().Value.Uuid != bootstrapSpec.ClusterUUID.ValueString() {
+		return fmt.Errorf("cluster UUID '%s' does not match the UUID '%s' of cluster '%s'",
+			bootstrapSpec.ClusterUUID.ValueString(), clusterUUID.TypedSpec().Value.Uuid, sourceCluster)
+	}
+
+	backups, err := listEtcdBackups(ctx, st, sourceCluster)
+	if err != nil {
+		return fmt.Errorf("failed to get etcd backups of cluster '%s': %v", sourceCluster, err)
+	}
+
+	return checkBackupSnapshot(sourceCluster, bootstrapSpec.Snapshot.ValueString(), backups)
+}
+
+// checkBackupSnapshot returns an error listing the available snapshots if the snapshot is not one of the backups.
+func checkBackupSnapshot(sourceCluster, snapshot string, backups []*omni.EtcdBackup) error {
+	snapshots := make([]string, 0, len(backups))
+
+	for _, backup := range backups {
+		if backup.TypedSpec().Value.Snapshot == snapshot {
+			return nil
+		}
+
+		snapshots = append(snapshots, backup.TypedSpec().Value.Snapshot)
+	}
+
+	if len(snapshots) == 0 {
+		return fmt.Errorf("etcd backup '%s' not found, cluster '%s' has no etcd backups", snapshot, sourceCluster)
+	}
+
+	return fmt.Errorf("etcd backup '%s' not found for cluster '%s', available backups: %s", snapshot, sourceCluster, strings.Join(snapshots, ", "))
+}
@@ -0,0 +1,204 @@
+package omni
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachineSetResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_machine_set" "test" {
+  cluster = "talos-default"
+  role    = "workers"
+  name    = "test"
+  machine_class = {
+    name          = "test-machine-class"
+    machine_count = 1
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_machine_set.test", "id", "talos-default-test"),
+				),
+			},
+			{
+				ResourceName:      "omni_machine_set.test",
+				ImportState:       true,
+				ImportStateId:     "talos-default-test",
+				ImportStateVerify: true,
+			},
+			{
+				Config: providerConfig + `
+resource "omni_machine_set" "restore" {
+  cluster = "restored"
+  role    = "control-plane"
+  machine_class = {
+    name          = "test-machine-class"
+    machine_count = 1
+  }
+  bootstrap_spec = {
+    source_cluster = "talos-default"
+    cluster_uuid   = "00000000-0000-0000-0000-000000000000"
+    snapshot       = "FFFFFFFFFFFFFFFF.snapshot"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`etcd backup 'FFFFFFFFFFFFFFFF.snapshot' not found|does not match the UUID`),
+			},
+		},
+	})
+}
+
+func TestMachineSetModelRoundTrip(t *testing.T) {
+	for _, model := range []machineSetResourceModel{
+		{
+			ID:      types.StringValue("prod-control-planes"),
+			Cluster: types.StringValue("prod"),
+			Role:    types.StringValue(machineSetRoleControlPlane),
+			Name:    types.StringNull(),
+			MachineClass: machineSetMachineClass{
+				Name:         types.StringValue("control-planes"),
+				MachineCount: types.Int64Value(3),
+				Unlimited:    types.BoolNull(),
+			},
+			BootstrapSpec: &machineSetBootstrapSpec{
+				SourceCluster: types.StringNull(),
+				ClusterUUID:   types.StringValue("4f7a1d3e-2c1b-4b8e-9f0a-8d6c5e4b3a21"),
+				Snapshot:      types.StringValue("FFFFFFFF9A2B3C4D.snapshot"),
+			},
+		},
+		{
+			ID:      types.StringValue("prod-workers"),
+			Cluster: types.StringValue("prod"),
+			Role:    types.StringValue(machineSetRoleWorkers),
+			Name:    types.StringNull(),
+			MachineClass: machineSetMachineClass{
+				Name:         types.StringValue("workers"),
+				MachineCount: types.Int64Null(),
+				Unlimited:    types.BoolValue(true),
+			},
+		},
+		{
+			ID:      types.StringValue("prod-gpu"),
+			Cluster: types.StringValue("prod"),
+			Role:    types.StringValue(machineSetRoleWorkers),
+			Name:    types.StringValue("gpu"),
+			MachineClass: machineSetMachineClass{
+				Name:         types.StringValue("gpu"),
+				MachineCount: types.Int64Value(2),
+				Unlimited:    types.BoolNull(),
+			},
+		},
+	} {
+		t.Run(model.ID.ValueString(), func(t *testing.T) {
+			require.Equal(t, model, machineSetModelFromResource(model.toMachineSet()))
+		})
+	}
+}
+
+func TestMachineSetImportKeepsBootstrapSpec(t *testing.T) {
+	st := newTestState()
+
+	config := machineSetResourceModel{
+		ID:      types.StringValue("restored-control-planes"),
+		Cluster: types.StringValue("restored"),
+		Role:    types.StringValue(machineSetRoleControlPlane),
+		Name:    types.StringNull(),
+		MachineClass: machineSetMachineClass{
+			Name:         types.StringValue("control-planes"),
+			MachineCount: types.Int64Value(1),
+			Unlimited:    types.BoolNull(),
+		},
+		BootstrapSpec: &machineSetBootstrapSpec{
+			SourceCluster: types.StringValue("prod"),
+			ClusterUUID:   types.StringValue("4f7a1d3e-2c1b-4b8e-9f0a-8d6c5e4b3a21"),
+			Snapshot:      types.StringValue("FFFFFFFF9A2B3C4D.snapshot"),
+		},
+	}
+	require.NoError(t, st.Create(t.Context(), config.toMachineSet()))
+
+	r := &machineSetResource{provider: &omniProvider{state: st}}
+
+	// the imported state only has the ID
+	importRead := func() machineSetResourceModel {
+		resp := readWithState(t, r, machineSetResourceModel{ID: config.ID})
+		require.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+
+		var imported machineSetResourceModel
+
+		require.False(t, resp.State.Get(t.Context(), &imported).HasError())
+
+		return imported
+	}
+
+	// the source cluster was deleted, so it is unknown, but the machine set is not replaced for it
+	imported := importRead()
+	require.True(t, imported.BootstrapSpec.SourceCluster.IsNull())
+	require.False(t, bootstrapSpecReplaced(t, imported.BootstrapSpec, config.BootstrapSpec))
+
+	changed := *config.BootstrapSpec
+	changed.Snapshot = types.StringValue("FFFFFFFF9A2B3C4E.snapshot")
+	require.True(t, bootstrapSpecReplaced(t, imported.BootstrapSpec, &changed))
+
+	// the source cluster still exists, so the plan after the import is empty
+	prodUUID := omni.NewClusterUUID("prod")
+	prodUUID.TypedSpec().Value.Uuid = config.BootstrapSpec.ClusterUUID.ValueString()
+	require.NoError(t, st.Create(t.Context(), prodUUID))
+
+	require.Equal(t, config, importRead())
+}
+
+// bootstrapSpecReplaced runs the replacement check of the bootstrap spec like Terraform does on plan.
+func bootstrapSpecReplaced(t *testing.T, stateSpec, planSpec *machineSetBootstrapSpec) bool {
+	t.Helper()
+
+	attrTypes := map[string]attr.Type{
+		"source_cluster": types.StringType,
+		"cluster_uuid":   types.StringType,
+		"snapshot":       types.StringType,
+	}
+
+	stateValue, diags := types.ObjectValueFrom(t.Context(), attrTypes, stateSpec)
+	require.False(t, diags.HasError())
+
+	planValue, diags := types.ObjectValueFrom(t.Context(), attrTypes, planSpec)
+	require.False(t, diags.HasError())
+
+	var resp objectplanmodifier.RequiresReplaceIfFuncResponse
+
+	bootstrapSpecRequiresReplace(t.Context(), planmodifier.ObjectRequest{StateValue: stateValue, PlanValue: planValue}, &resp)
+	require.False(t, resp.Diagnostics.HasError())
+
+	return resp.RequiresReplace
+}
+
+func TestCheckBackupSnapshot(t *testing.T) {
+	now := time.Now()
+
+	first := omni.NewEtcdBackup("prod", now.Add(-time.Hour))
+	first.TypedSpec().Value.Snapshot = "FFFFFFFF9A2B3C4D.snapshot"
+
+	second := omni.NewEtcdBackup("prod", now)
+	second.TypedSpec().Value.Snapshot = "FFFFFFFF9A2B3C4E.snapshot"
+
+	backups := []*omni.EtcdBackup{second, first}
+
+	require.NoError(t, checkBackupSnapshot("prod", "FFFFFFFF9A2B3C4D.snapshot", backups))
+	require.EqualError(t, checkBackupSnapshot("prod", "missing.snapshot", backups),
+		"etcd backup 'missing.snapshot' not found for cluster 'prod', available backups: FFFFFFFF9A2B3C4E.snapshot, FFFFFFFF9A2B3C4D.snapshot")
+	require.EqualError(t, checkBackupSnapshot("prod", "missing.snapshot", nil),
+		"etcd backup 'missing.snapshot' not found, cluster 'prod' has no etcd backups")
+}
@@ -34,6 +34,7 @@ type MachineModel struct {
 	ID        types.String `tfsdk:"id"`
 	Connected types.Bool   `tfsdk:"connected"`
 	Cluster   types.String `tfsdk:"cluster"`
+	Labels    types.Map    `tfsdk:"labels"`
 }
 
 func (d *machinesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -61,6 +62,11 @@ func (d *machinesDataSource) Schema(ctx context.Context, req datasource.SchemaRe
 							MarkdownDescription: "The cluster this machine is assigned to",
 							Computed:            true,
 						},
+						"labels": schema.MapAttribute{
+							MarkdownDescription: "The labels of the machine which the machine class selectors match, e.g. `omni.sidero.dev/arch`",
+							ElementType:         types.StringType,
+							Computed:            true,
+						},
 					},
 				},
 			},
@@ -89,7 +95,7 @@ func (d *machinesDataSource) Configure(ctx context.Context, req datasource.Confi
 func (d *machinesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var data MachinesDataSourceModel
 
-	st := d.provider.client.Omni().State()
+	st := d.provider.state
 
 	machines, err := safe.StateList[*omni.MachineStatus](ctx, st, omni.NewMachineStatus(resources.DefaultNamespace, "").Metadata())
 	if err != nil {
@@ -107,10 +113,17 @@ func (d *machinesDataSource) Read(ctx context.Context, req datasource.ReadReques
 			clusterName = val
 		}
 
+		labels, diags := types.MapValueFrom(ctx, types.StringType, item.Metadata().Labels().Raw())
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
 		machinesList = append(machinesList, MachineModel{
 			ID:        types.StringValue(item.Metadata().ID()),
 			Connected: types.BoolValue(item.TypedSpec().Value.GetConnected()),
 			Cluster:   types.StringValue(clusterName),
+			Labels:    labels,
 		})
 	}
 
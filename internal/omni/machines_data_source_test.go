@@ -0,0 +1,29 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestMachinesDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccOfflineProviderFactories(t),
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "omni_machines" "all" {}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.omni_machines.all", "machines.#", "2"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.omni_machines.all", "machines.*", map[string]string{
+						"id":                          "2b9c6f1e-4a7d-4f3b-8e2a-1c5d9e7f3a10",
+						"connected":                   "true",
+						"cluster":                     "talos-default",
+						"labels.omni.sidero.dev/arch": "amd64",
+					}),
+				),
+			},
+		},
+	})
+}
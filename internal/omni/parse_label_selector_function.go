@@ -0,0 +1,113 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/cosi/labels"
+)
+
+// labelTermAttrTypes are the attributes of a term returned by parse_label_selector.
+var labelTermAttrTypes = map[string]attr.Type{
+	"key":      types.StringType,
+	"operator": types.StringType,
+	"values":   types.ListType{ElemType: types.StringType},
+}
+
+var _ function.Function = &parseLabelSelectorFunction{}
+
+func NewParseLabelSelectorFunction() function.Function {
+	return &parseLabelSelectorFunction{}
+}
+
+type parseLabelSelectorFunction struct{}
+
+func (f *parseLabelSelectorFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_label_selector"
+}
+
+func (f *parseLabelSelectorFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Parse an Omni label selector",
+		MarkdownDescription: "Parses a label selector, like the `match_labels` of `omni_machine_class`, with the grammar of Omni and " +
+			"returns its terms. A machine must match all the terms. The `operator` of a term is one of `exists`, `!exists`, `=`, `!=`, " +
+			"`in`, `notin`, `<`, `<=`, `>` and `>=`, the `values` are empty for `exists` and `!exists`. The function fails on invalid selectors.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "selector",
+				MarkdownDescription: "The label selector, e.g. `omni.sidero.dev/arch = amd64, !omni.sidero.dev/cluster`.",
+			},
+		},
+		Return: function.ListReturn{
+			ElementType: types.ObjectType{AttrTypes: labelTermAttrTypes},
+		},
+	}
+}
+
+func (f *parseLabelSelectorFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var selector string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &selector))
+	if resp.Error != nil {
+		return
+	}
+
+	query, err := labels.ParseQuery(selector)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Label selector %q is invalid: %v", selector, err))
+		return
+	}
+
+	terms := make([]attr.Value, 0, len(query.Terms))
+
+	for _, term := range query.Terms {
+		values, diags := types.ListValueFrom(ctx, types.StringType, append([]string{}, term.Value...))
+		if diags.HasError() {
+			resp.Error = function.FuncErrorFromDiags(ctx, diags)
+			return
+		}
+
+		terms = append(terms, types.ObjectValueMust(labelTermAttrTypes, map[string]attr.Value{
+			"key":      types.StringValue(term.Key),
+			"operator": types.StringValue(labelTermOperator(term)),
+			"values":   values,
+		}))
+	}
+
+	resp.Error = resp.Result.Set(ctx, types.ListValueMust(types.ObjectType{AttrTypes: labelTermAttrTypes}, terms))
+}
+
+// labelTermOperator returns the operator of the selector grammar the term was parsed from,
+// Omni parses `>` and `>=` as the inverted `<=` and `<`.
+func labelTermOperator(term resource.LabelTerm) string {
+	var operator, inverted string
+
+	switch term.Op { //nolint:exhaustive
+	case resource.LabelOpExists:
+		operator, inverted = "exists", "!exists"
+	case resource.LabelOpEqual:
+		operator, inverted = "=", "!="
+	case resource.LabelOpIn:
+		operator, inverted = "in", "notin"
+	case resource.LabelOpLTNumeric:
+		operator, inverted = "<", ">="
+	case resource.LabelOpLTENumeric:
+		operator, inverted = "<=", ">"
+	default:
+		return fmt.Sprintf("unknown(%d)", term.Op)
+	}
+
+	if term.Invert {
+		return inverted
+	}
+
+	return operator
+}
@@ -0,0 +1,48 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelSelectorFunction(t *testing.T) {
+	resp := &function.RunResponse{Result: function.NewResultData(types.ListUnknown(types.ObjectType{AttrTypes: labelTermAttrTypes}))}
+
+	(&parseLabelSelectorFunction{}).Run(t.Context(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("omni.sidero.dev/arch = amd64, !omni.sidero.dev/cluster, zone in (a, b), cores > 4, disks notin (0)"),
+		}),
+	}, resp)
+	require.Nil(t, resp.Error)
+
+	type term struct {
+		Key      string   `tfsdk:"key"`
+		Operator string   `tfsdk:"operator"`
+		Values   []string `tfsdk:"values"`
+	}
+
+	var terms []term
+
+	require.False(t, resp.Result.Value().(types.List).ElementsAs(t.Context(), &terms, false).HasError()) //nolint:forcetypeassert
+	require.Equal(t, []term{
+		{Key: "omni.sidero.dev/arch", Operator: "=", Values: []string{"amd64"}},
+		{Key: "omni.sidero.dev/cluster", Operator: "!exists", Values: []string{}},
+		{Key: "zone", Operator: "in", Values: []string{"a", "b"}},
+		{Key: "cores", Operator: ">", Values: []string{"4"}},
+		{Key: "disks", Operator: "notin", Values: []string{"0"}},
+	}, terms)
+}
+
+func TestParseLabelSelectorFunctionInvalid(t *testing.T) {
+	resp := &function.RunResponse{Result: function.NewResultData(types.ListUnknown(types.ObjectType{AttrTypes: labelTermAttrTypes}))}
+
+	(&parseLabelSelectorFunction{}).Run(t.Context(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("a ~ b")}),
+	}, resp)
+	require.NotNil(t, resp.Error)
+	require.Contains(t, resp.Error.Error(), `Label selector "a ~ b" is invalid`)
+}
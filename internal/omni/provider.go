@@ -6,14 +6,19 @@ package omni
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/siderolabs/omni/client/pkg/client"
@@ -25,11 +30,36 @@ var _ provider.Provider = &omniProvider{}
 
 type omniProvider struct {
 	client *client.Client
+
+	// state and management are used by the resources and data sources to access Omni.
+	state      state.State
+	management managementClient
+
+	// overrideClient is used instead of connecting to the configured endpoint when set.
+	overrideClient *client.Client
+
+	// recorder records the Omni API calls when OMNI_PROVIDER_RECORD is set.
+	recorder *cassetteRecorder
+
+	// retry is the policy of the calls which can be repeated safely.
+	retry retryPolicy
+}
+
+// Option configures the provider.
+type Option func(*omniProvider)
+
+// WithClient makes the provider use the given Omni client instead of connecting to the configured endpoint,
+// e.g. to run the acceptance tests against a local Omni stand-in.
+func WithClient(omniClient *client.Client) Option {
+	return func(p *omniProvider) {
+		p.overrideClient = omniClient
+	}
 }
 
 type omniProviderModel struct {
-	Endpoint          types.String `tfsdk:"endpoint"`
-	ServiceAccountKey types.String `tfsdk:"service_account_key"`
+	Endpoint          types.String      `tfsdk:"endpoint"`
+	ServiceAccountKey types.String      `tfsdk:"service_account_key"`
+	Retry             *retryPolicyModel `tfsdk:"retry"`
 }
 
 func (p *omniProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -48,6 +78,32 @@ func (p *omniProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 				Required:            true,
 				Sensitive:           true,
 			},
+			"retry": schema.SingleNestedAttribute{
+				MarkdownDescription: "The retry policy of the reads and of the updates which hit a version conflict when " +
+					"Omni is unavailable or does not answer in time. Defaults to 3 attempts with a backoff from `250ms` up to `5s`.",
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"max_attempts": schema.Int64Attribute{
+						MarkdownDescription: "The maximum number of attempts of a call, `1` disables the retries. Defaults to `3`.",
+						Optional:            true,
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"base_backoff": schema.StringAttribute{
+						MarkdownDescription: "The delay before the first retry, it doubles with every retry. Defaults to `250ms`.",
+						Optional:            true,
+					},
+					"max_backoff": schema.StringAttribute{
+						MarkdownDescription: "The maximum delay between the retries. Defaults to `5s`.",
+						Optional:            true,
+					},
+					"jitter": schema.BoolAttribute{
+						MarkdownDescription: "Randomize the delays between the retries, so concurrent calls do not retry at the same time. Defaults to `true`.",
+						Optional:            true,
+					},
+				},
+			},
 		},
 	}
 }
@@ -80,57 +136,167 @@ func (p *omniProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		return
 	}
 
-	// Create Omni client
-	omniClient, err := client.New(
-		config.Endpoint.ValueString(),
-		client.WithServiceAccount(config.ServiceAccountKey.ValueString()),
-	)
-	if err != nil {
+	retry, diags := config.Retry.policy()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.retry = retry
+
+	recordPath, replayPath := os.Getenv(recordEnvVar), os.Getenv(replayEnvVar)
+
+	if recordPath != "" && replayPath != "" {
 		resp.Diagnostics.AddError(
-			"Failed to Create Omni Client",
-			fmt.Sprintf("Failed to create Omni client: %s", err),
+			"Conflicting Record and Replay Configuration",
+			fmt.Sprintf("Only one of %s and %s can be set", recordEnvVar, replayEnvVar),
 		)
 		return
 	}
 
+	// Serve the recorded calls without connecting to Omni
+	if replayPath != "" {
+		replayer, err := loadCassette(replayPath)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to Load Cassette",
+				fmt.Sprintf("Failed to load the cassette %s: %s", replayPath, err),
+			)
+			return
+		}
+
+		p.state = state.WrapCore(retryingState{CoreState: replayingState{replayer: replayer}, policy: retry})
+		p.management = retryingManagement{managementClient: replayingManagement{replayer: replayer}, policy: retry}
+
+		resp.ResourceData = p
+		resp.DataSourceData = p
+		resp.EphemeralResourceData = p
+
+		return
+	}
+
+	// Create Omni client
+	omniClient := p.overrideClient
+	if omniClient == nil {
+		var err error
+
+		omniClient, err = client.New(
+			config.Endpoint.ValueString(),
+			client.WithServiceAccount(config.ServiceAccountKey.ValueString()),
+		)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to Create Omni Client",
+				fmt.Sprintf("Failed to create Omni client: %s", err),
+			)
+			return
+		}
+	}
+
 	p.client = omniClient
+	p.state = omniClient.Omni().State()
+	p.management = clientManagement{omniClient.Management()}
+
+	if recordPath != "" {
+		recorder, err := newCassetteRecorder(recordPath)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to Create Cassette",
+				fmt.Sprintf("Failed to record to the cassette %s: %s", recordPath, err),
+			)
+			return
+		}
+
+		p.recorder = recorder
+		p.state = state.WrapCore(recordingState{core: p.state, recorder: recorder})
+		p.management = recordingManagement{client: p.management, recorder: recorder}
+	}
+
+	p.state = state.WrapCore(retryingState{CoreState: p.state, policy: retry})
+	p.management = retryingManagement{managementClient: p.management, policy: retry}
 
 	// Make the client available to resources and data sources
 	resp.ResourceData = p
 	resp.DataSourceData = p
+	resp.EphemeralResourceData = p
 }
 
 func (p *omniProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewApplyYamlResource,
+		NewServiceAccountResource,
+		NewUserResource,
+		NewAccessPolicyResource,
+		NewMachineLabelsResource,
+		NewMachineClassResource,
+		NewMachineSetResource,
+		NewSchematicResource,
+		NewClusterKubernetesUpgradeResource,
+		NewClusterTalosUpgradeResource,
+		NewKubernetesManifestsSyncResource,
+		NewEtcdBackupS3ConfigResource,
+		NewEtcdManualBackupResource,
 	}
 }
 
 func (p *omniProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
-	return []func() ephemeral.EphemeralResource{}
+	return []func() ephemeral.EphemeralResource{
+		NewServiceAccountKeyEphemeralResource,
+	}
 }
 
 func (p *omniProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewMachinesDataSource,
 		NewInstallationMediaDataSource,
+		NewInstallationMediasDataSource,
+		NewTalosVersionsDataSource,
+		NewKubernetesVersionsDataSource,
+		NewTalosExtensionsDataSource,
+		NewEtcdBackupsDataSource,
 	}
 }
 
 func (p *omniProvider) Functions(ctx context.Context) []func() function.Function {
-	return []func() function.Function{}
+	return []func() function.Function{
+		NewDecodeResourcesFunction,
+		NewEncodeResourceFunction,
+		NewResourceIDFunction,
+		NewSchematicIDFunction,
+		NewParseLabelSelectorFunction,
+		NewLabelSelectorMatchesFunction,
+		NewTalosInstallPatchFunction,
+		NewTalosNetworkInterfacesPatchFunction,
+		NewTalosNodeLabelsPatchFunction,
+		NewTalosKubeletExtraArgsPatchFunction,
+		NewTalosSysctlsPatchFunction,
+		NewTalosRegistryMirrorsPatchFunction,
+	}
 }
 
 func (p *omniProvider) Shutdown(ctx context.Context) error {
-	if p.client != nil {
-		return p.client.Close()
+	var errs []error
+
+	if p.recorder != nil {
+		errs = append(errs, p.recorder.Close())
 	}
 
-	return nil
+	// The override client is owned by the caller
+	if p.client != nil && p.client != p.overrideClient {
+		errs = append(errs, p.client.Close())
+	}
+
+	return errors.Join(errs...)
 }
 
-func New() func() provider.Provider {
+func New(opts ...Option) func() provider.Provider {
 	return func() provider.Provider {
-		return &omniProvider{}
+		p := &omniProvider{}
+
+		for _, opt := range opts {
+			opt(p)
+		}
+
+		return p
 	}
 }
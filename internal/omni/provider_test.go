@@ -1,8 +1,16 @@
 package omni
 
 import (
+	"testing"
+
+	"github.com/cosi-project/runtime/pkg/resource"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+
+	"github.com/stathis-ditc/terraform-provider-omni/internal/omnitest"
 )
 
 const (
@@ -27,3 +35,56 @@ var (
 		"omni": providerserver.NewProtocol6WithError(New()()),
 	}
 )
+
+// testAccOfflineProviderFactories starts a local Omni stand-in seeded with the test fixtures
+// and returns provider factories connected to it instead of the endpoint in providerConfig.
+func testAccOfflineProviderFactories(t *testing.T) map[string]func() (tfprotov6.ProviderServer, error) {
+	srv := omnitest.NewServer(t)
+	srv.Seed(t, testFixtures()...)
+
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"omni": providerserver.NewProtocol6WithError(New(WithClient(srv.Client()))()),
+	}
+}
+
+// testFixtures returns the resources managed by Omni itself which the offline tests rely on.
+func testFixtures() []resource.Resource {
+	var fixtures []resource.Resource
+
+	for id, spec := range map[string]*specs.InstallationMediaSpec{
+		"iso-amd64.iso":            {Name: "ISO (amd64)", Architecture: "amd64", Profile: "metal", SrcFilePrefix: "metal-amd64", Extension: "iso"},
+		"iso-arm64.iso":            {Name: "ISO (arm64)", Architecture: "arm64", Profile: "metal", SrcFilePrefix: "metal-arm64", Extension: "iso"},
+		"aws-amd64.raw.xz":         {Name: "AWS (amd64)", Architecture: "amd64", Profile: "aws", SrcFilePrefix: "aws-amd64", Extension: "raw.xz"},
+		"rpi_generic-arm64.raw.xz": {Name: "Raspberry Pi Series", Architecture: "arm64", Profile: "metal", SrcFilePrefix: "metal-rpi_generic-arm64", Extension: "raw.xz", Overlay: "rpi_generic", NoSecureBoot: true},
+	} {
+		media := omni.NewInstallationMedia(resources.EphemeralNamespace, id)
+		media.TypedSpec().Value = spec
+
+		fixtures = append(fixtures, media)
+	}
+
+	extensions := omni.NewTalosExtensions(resources.DefaultNamespace, "1.9.5")
+	extensions.TypedSpec().Value.Items = []*specs.TalosExtensionsSpec_Info{
+		{Name: "siderolabs/iscsi-tools", Version: "v0.1.6", Author: "Sidero Labs", Description: "This system extension provides iscsi-tools."},
+		{Name: "siderolabs/qemu-guest-agent", Version: "9.2.0", Author: "Sidero Labs", Description: "This system extension provides the QEMU Guest Agent service."},
+	}
+
+	fixtures = append(fixtures, extensions)
+
+	for id, clusterName := range map[string]string{
+		"2b9c6f1e-4a7d-4f3b-8e2a-1c5d9e7f3a10": "talos-default",
+		"7e3a1d5c-9b2f-4c8e-a6d4-3f1b8c2e5a97": "",
+	} {
+		machine := omni.NewMachineStatus(resources.DefaultNamespace, id)
+		machine.TypedSpec().Value.Connected = true
+		machine.Metadata().Labels().Set(omni.MachineStatusLabelArch, "amd64")
+
+		if clusterName != "" {
+			machine.Metadata().Labels().Set(omni.LabelCluster, clusterName)
+		}
+
+		fixtures = append(fixtures, machine)
+	}
+
+	return fixtures
+}
@@ -0,0 +1,75 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+
+	cosi_res "github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/resource/protobuf"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &resourceIDFunction{}
+
+func NewResourceIDFunction() function.Function {
+	return &resourceIDFunction{}
+}
+
+type resourceIDFunction struct{}
+
+func (f *resourceIDFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "resource_id"
+}
+
+func (f *resourceIDFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Build the key of an Omni resource",
+		MarkdownDescription: "Returns the key of a resource as `namespace/type/id`, the format of the keys of the `versions` " +
+			"of `omni_apply_yaml`. The resource type must be known to Omni.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "type",
+				MarkdownDescription: "The resource type, e.g. `MachineClasses.omni.sidero.dev`.",
+			},
+			function.StringParameter{
+				Name:                "namespace",
+				MarkdownDescription: "The resource namespace, e.g. `default`.",
+			},
+			function.StringParameter{
+				Name:                "id",
+				MarkdownDescription: "The resource ID.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *resourceIDFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var resourceType, namespace, id string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &resourceType, &namespace, &id))
+	if resp.Error != nil {
+		return
+	}
+
+	if _, err := protobuf.CreateResource(resourceType); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Unknown resource type %q", resourceType))
+		return
+	}
+
+	if namespace == "" {
+		resp.Error = function.NewArgumentFuncError(1, "The namespace must not be empty")
+		return
+	}
+
+	if id == "" {
+		resp.Error = function.NewArgumentFuncError(2, "The id must not be empty")
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, pointerKey(cosi_res.NewMetadata(namespace, resourceType, id, cosi_res.VersionUndefined)))
+}
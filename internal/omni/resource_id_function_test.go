@@ -0,0 +1,58 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceIDFunction(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		errArg   *int64
+	}{
+		{
+			name:     "valid",
+			args:     []string{"MachineClasses.omni.sidero.dev", "default", "workers"},
+			expected: "default/MachineClasses.omni.sidero.dev/workers",
+		},
+		{
+			name:   "unknown type",
+			args:   []string{"Unknown.omni.sidero.dev", "default", "workers"},
+			errArg: new(int64),
+		},
+		{
+			name:   "empty id",
+			args:   []string{"MachineClasses.omni.sidero.dev", "default", ""},
+			errArg: func() *int64 { i := int64(2); return &i }(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := make([]attr.Value, 0, len(tt.args))
+			for _, arg := range tt.args {
+				args = append(args, types.StringValue(arg))
+			}
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+
+			(&resourceIDFunction{}).Run(t.Context(), function.RunRequest{Arguments: function.NewArgumentsData(args)}, resp)
+
+			if tt.errArg != nil {
+				require.NotNil(t, resp.Error)
+				require.Equal(t, tt.errArg, resp.Error.FunctionArgument)
+
+				return
+			}
+
+			require.Nil(t, resp.Error)
+			require.Equal(t, types.StringValue(tt.expected), resp.Result.Value())
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultRetryPolicy is used when the provider block has no retry settings.
+var defaultRetryPolicy = retryPolicy{
+	maxAttempts: 3,
+	baseBackoff: 250 * time.Millisecond,
+	maxBackoff:  5 * time.Second,
+	jitter:      true,
+}
+
+// retryPolicy retries the calls failing with transient errors, the zero value makes a single attempt.
+type retryPolicy struct {
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	jitter      bool
+}
+
+type retryPolicyModel struct {
+	MaxAttempts types.Int64  `tfsdk:"max_attempts"`
+	BaseBackoff types.String `tfsdk:"base_backoff"`
+	MaxBackoff  types.String `tfsdk:"max_backoff"`
+	Jitter      types.Bool   `tfsdk:"jitter"`
+}
+
+// policy returns the retry policy with the defaults of the unset attributes.
+func (m *retryPolicyModel) policy() (retryPolicy, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	policy := defaultRetryPolicy
+
+	if m == nil {
+		return policy, diags
+	}
+
+	if !m.MaxAttempts.IsNull() && !m.MaxAttempts.IsUnknown() {
+		policy.maxAttempts = int(m.MaxAttempts.ValueInt64())
+	}
+
+	if !m.Jitter.IsNull() && !m.Jitter.IsUnknown() {
+		policy.jitter = m.Jitter.ValueBool()
+	}
+
+	for _, backoff := range []struct {
+		value  types.String
+		target *time.Duration
+		name   string
+	}{
+		{m.BaseBackoff, &policy.baseBackoff, "base_backoff"},
+		{m.MaxBackoff, &policy.maxBackoff, "max_backoff"},
+	} {
+		if backoff.value.IsNull() || backoff.value.IsUnknown() {
+			continue
+		}
+
+		d, err := time.ParseDuration(backoff.value.ValueString())
+		if err != nil || d < 0 {
+			diags.AddAttributeError(
+				path.Root("retry").AtName(backoff.name),
+				"Invalid Retry Backoff",
+				fmt.Sprintf("The %s must be a positive duration like \"500ms\", got %q", backoff.name, backoff.value.ValueString()),
+			)
+
+			continue
+		}
+
+		*backoff.target = d
+	}
+
+	if policy.maxBackoff < policy.baseBackoff {
+		diags.AddAttributeError(
+			path.Root("retry").AtName("max_backoff"),
+			"Invalid Retry Backoff",
+			fmt.Sprintf("The max_backoff %s must not be shorter than the base_backoff %s", policy.maxBackoff, policy.baseBackoff),
+		)
+	}
+
+	return policy, diags
+}
+
+// do calls fn until it succeeds, returns an error which is not retryable or the attempts are used up.
+func (p retryPolicy) do(ctx context.Context, retryable func(error) bool, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.maxAttempts || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.backoff(attempt)):
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt, it doubles with every attempt up to the max backoff.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseBackoff
+
+	for range attempt - 1 {
+		if delay >= p.maxBackoff {
+			break
+		}
+
+		delay *= 2
+	}
+
+	delay = min(delay, p.maxBackoff)
+
+	if p.jitter && delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1) //nolint:gosec
+	}
+
+	return delay
+}
+
+// isTransientError reports whether the call failed because Omni could not be reached or did not answer in time.
+func isTransientError(err error) bool {
+	switch status.Code(err) { //nolint:exhaustive
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// isVersionConflictError reports whether an update failed because the resource was changed since it was read.
+func isVersionConflictError(err error) bool {
+	return state.IsConflictError(err) && !state.IsOwnerConflictError(err) && !state.IsPhaseConflictError(err)
+}
+
+// isRetryableUpdateError reports whether an update with the version read again can succeed.
+func isRetryableUpdateError(err error) bool {
+	return isVersionConflictError(err) || isTransientError(err)
+}
+
+// retryingState is a state.CoreState which retries the reads failing with transient errors.
+//
+// The writes are passed through, the callers decide whether a write can be repeated.
+type retryingState struct {
+	state.CoreState
+
+	policy retryPolicy
+}
+
+func (s retryingState) Get(ctx context.Context, ptr resource.Pointer, opts ...state.GetOption) (resource.Resource, error) { //nolint:ireturn
+	var r resource.Resource
+
+	err := s.policy.do(ctx, isTransientError, func() error {
+		var err error
+
+		r, err = s.CoreState.Get(ctx, ptr, opts...)
+
+		return err
+	})
+
+	return r, err
+}
+
+func (s retryingState) List(ctx context.Context, kind resource.Kind, opts ...state.ListOption) (resource.List, error) {
+	var list resource.List
+
+	err := s.policy.do(ctx, isTransientError, func() error {
+		var err error
+
+		list, err = s.CoreState.List(ctx, kind, opts...)
+
+		return err
+	})
+
+	return list, err
+}
+
+// retryingManagement is a managementClient which retries the idempotent calls failing with transient errors.
+type retryingManagement struct {
+	managementClient
+
+	policy retryPolicy
+}
+
+func (m retryingManagement) CreateSchematic(ctx context.Context, req *management.CreateSchematicRequest) (*management.CreateSchematicResponse, error) {
+	var resp *management.CreateSchematicResponse
+
+	err := m.policy.do(ctx, isTransientError, func() error {
+		var err error
+
+		resp, err = m.managementClient.CreateSchematic(ctx, req)
+
+		return err
+	})
+
+	return resp, err
+}
+
+func (m retryingManagement) ListServiceAccounts(ctx context.Context) ([]*management.ListServiceAccountsResponse_ServiceAccount, error) {
+	var accounts []*management.ListServiceAccountsResponse_ServiceAccount
+
+	err := m.policy.do(ctx, isTransientError, func() error {
+		var err error
+
+		accounts, err = m.managementClient.ListServiceAccounts(ctx)
+
+		return err
+	})
+
+	return accounts, err
+}
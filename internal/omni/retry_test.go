@@ -0,0 +1,326 @@
+package omni
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyState fails the first calls of the core state with the given errors and runs beforeUpdate on the first update.
+// The updateErrors are returned after the update was applied, like a call that timed out after it landed.
+type flakyState struct {
+	state.CoreState
+
+	getErrors     []error
+	updateErrors  []error
+	destroyErrors []error
+	beforeUpdate  func()
+}
+
+func (s *flakyState) Get(ctx context.Context, ptr resource.Pointer, opts ...state.GetOption) (resource.Resource, error) { //nolint:ireturn
+	if len(s.getErrors) > 0 {
+		err := s.getErrors[0]
+		s.getErrors = s.getErrors[1:]
+
+		return nil, err
+	}
+
+	return s.CoreState.Get(ctx, ptr, opts...)
+}
+
+func (s *flakyState) Update(ctx context.Context, r resource.Resource, opts ...state.UpdateOption) error {
+	if s.beforeUpdate != nil {
+		s.beforeUpdate()
+		s.beforeUpdate = nil
+	}
+
+	if err := s.CoreState.Update(ctx, r, opts...); err != nil {
+		return err
+	}
+
+	if len(s.updateErrors) > 0 {
+		err := s.updateErrors[0]
+		s.updateErrors = s.updateErrors[1:]
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *flakyState) Destroy(ctx context.Context, ptr resource.Pointer, opts ...state.DestroyOption) error {
+	if len(s.destroyErrors) > 0 {
+		err := s.destroyErrors[0]
+		s.destroyErrors = s.destroyErrors[1:]
+
+		return err
+	}
+
+	return s.CoreState.Destroy(ctx, ptr, opts...)
+}
+
+var testRetryPolicy = retryPolicy{
+	maxAttempts: 3,
+	baseBackoff: time.Millisecond,
+	maxBackoff:  time.Millisecond,
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := retryPolicy{baseBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+
+	var delays []time.Duration
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		delays = append(delays, policy.backoff(attempt))
+	}
+
+	require.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}, delays)
+
+	policy.jitter = true
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		delay := policy.backoff(attempt)
+		require.GreaterOrEqual(t, delay, delays[attempt-1]/2)
+		require.LessOrEqual(t, delay, delays[attempt-1])
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	tests := []struct {
+		name     string
+		policy   retryPolicy
+		errs     []error
+		attempts int
+		wantErr  bool
+	}{
+		{
+			name:     "succeeds after transient errors",
+			policy:   testRetryPolicy,
+			errs:     []error{unavailable, status.Error(codes.DeadlineExceeded, "timeout")},
+			attempts: 3,
+		},
+		{
+			name:     "gives up after max attempts",
+			policy:   testRetryPolicy,
+			errs:     []error{unavailable, unavailable, unavailable, unavailable},
+			attempts: 3,
+			wantErr:  true,
+		},
+		{
+			name:     "does not retry permanent errors",
+			policy:   testRetryPolicy,
+			errs:     []error{status.Error(codes.PermissionDenied, "denied")},
+			attempts: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "zero policy makes a single attempt",
+			errs:     []error{unavailable},
+			attempts: 1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+
+			err := tt.policy.do(t.Context(), isTransientError, func() error {
+				attempts++
+
+				if attempts <= len(tt.errs) {
+					return tt.errs[attempts-1]
+				}
+
+				return nil
+			})
+
+			require.Equal(t, tt.attempts, attempts)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyModel(t *testing.T) {
+	var model *retryPolicyModel
+
+	policy, diags := model.policy()
+	require.False(t, diags.HasError())
+	require.Equal(t, defaultRetryPolicy, policy)
+
+	policy, diags = (&retryPolicyModel{
+		MaxAttempts: types.Int64Value(5),
+		BaseBackoff: types.StringValue("1s"),
+		MaxBackoff:  types.StringNull(),
+		Jitter:      types.BoolValue(false),
+	}).policy()
+	require.False(t, diags.HasError())
+	require.Equal(t, retryPolicy{maxAttempts: 5, baseBackoff: time.Second, maxBackoff: 5 * time.Second}, policy)
+
+	_, diags = (&retryPolicyModel{BaseBackoff: types.StringValue("soon")}).policy()
+	require.True(t, diags.HasError())
+
+	_, diags = (&retryPolicyModel{BaseBackoff: types.StringValue("10s"), MaxBackoff: types.StringValue("1s")}).policy()
+	require.True(t, diags.HasError())
+}
+
+func TestRetryingStateRetriesReads(t *testing.T) {
+	core := &flakyState{
+		CoreState: newTestState(),
+		getErrors: []error{status.Error(codes.Unavailable, "connection refused")},
+	}
+
+	st := state.WrapCore(retryingState{CoreState: core, policy: testRetryPolicy})
+
+	_, err := st.Get(t.Context(), omni.NewMachineClass(resources.DefaultNamespace, "missing").Metadata())
+	require.True(t, state.IsNotFoundError(err))
+	require.Empty(t, core.getErrors)
+}
+
+func TestApplyYamlRetriesVersionConflicts(t *testing.T) {
+	inner := newTestState()
+
+	class := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	class.TypedSpec().Value.MatchLabels = []string{"a"}
+	require.NoError(t, inner.Create(t.Context(), class))
+
+	core := &flakyState{
+		CoreState: inner,
+		// the resource is changed between the read and the update of the provider
+		beforeUpdate: func() {
+			_, err := safe.StateUpdateWithConflicts(t.Context(), inner, class.Metadata(), func(r *omni.MachineClass) error {
+				r.TypedSpec().Value.MatchLabels = []string{"b"}
+
+				return nil
+			})
+			require.NoError(t, err)
+		},
+		destroyErrors: []error{status.Error(codes.Unavailable, "connection refused")},
+	}
+
+	st := state.WrapCore(retryingState{CoreState: core, policy: testRetryPolicy})
+	r := &applyYamlResource{provider: &omniProvider{state: st, retry: testRetryPolicy}}
+
+	updated := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	updated.TypedSpec().Value.MatchLabels = []string{"c"}
+
+	require.NoError(t, r.processResource(t.Context(), st, updated, nil, ModeCreateOrUpdate))
+
+	result, err := safe.StateGetByID[*omni.MachineClass](t.Context(), inner, "workers")
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, result.TypedSpec().Value.MatchLabels)
+
+	require.NoError(t, r.deleteResource(t.Context(), st, updated))
+
+	_, err = inner.Get(t.Context(), class.Metadata())
+	require.True(t, state.IsNotFoundError(err))
+}
+
+func TestApplyYamlStrictVersioningDoesNotOverwriteConcurrentChanges(t *testing.T) {
+	inner := newTestState()
+
+	class := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	class.TypedSpec().Value.MatchLabels = []string{"a"}
+	require.NoError(t, inner.Create(t.Context(), class))
+
+	core := &flakyState{
+		CoreState: inner,
+		// the resource is changed outside of Terraform between the read and the update of the provider
+		beforeUpdate: func() {
+			_, err := safe.StateUpdateWithConflicts(t.Context(), inner, class.Metadata(), func(r *omni.MachineClass) error {
+				r.TypedSpec().Value.MatchLabels = []string{"b"}
+
+				return nil
+			})
+			require.NoError(t, err)
+		},
+	}
+
+	st := state.WrapCore(retryingState{CoreState: core, policy: testRetryPolicy})
+	r := &applyYamlResource{provider: &omniProvider{state: st, retry: testRetryPolicy}}
+
+	updated := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	updated.TypedSpec().Value.MatchLabels = []string{"c"}
+
+	err := r.processResource(t.Context(), st, updated, class, ModeCreateOrUpdate)
+	require.ErrorContains(t, err, "was changed outside of Terraform during the apply")
+
+	result, err := safe.StateGetByID[*omni.MachineClass](t.Context(), inner, "workers")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, result.TypedSpec().Value.MatchLabels)
+}
+
+func TestApplyYamlStrictVersioningAcceptsTimedOutUpdateThatLanded(t *testing.T) {
+	inner := newTestState()
+
+	class := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	class.TypedSpec().Value.MatchLabels = []string{"a"}
+	require.NoError(t, inner.Create(t.Context(), class))
+
+	core := &flakyState{
+		CoreState:    inner,
+		updateErrors: []error{status.Error(codes.DeadlineExceeded, "context deadline exceeded")},
+	}
+
+	st := state.WrapCore(retryingState{CoreState: core, policy: testRetryPolicy})
+	r := &applyYamlResource{provider: &omniProvider{state: st, retry: testRetryPolicy}}
+
+	updated := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	updated.TypedSpec().Value.MatchLabels = []string{"c"}
+
+	// the retried update conflicts with the first one, which already applied the resource
+	require.NoError(t, r.processResource(t.Context(), st, updated, class, ModeCreateOrUpdate))
+
+	result, err := safe.StateGetByID[*omni.MachineClass](t.Context(), inner, "workers")
+	require.NoError(t, err)
+	require.Equal(t, []string{"c"}, result.TypedSpec().Value.MatchLabels)
+}
+
+func TestApplyYamlDoesNotRetryWithoutPolicy(t *testing.T) {
+	inner := newTestState()
+
+	class := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	require.NoError(t, inner.Create(t.Context(), class))
+
+	core := &flakyState{
+		CoreState: inner,
+		beforeUpdate: func() {
+			_, err := safe.StateUpdateWithConflicts(t.Context(), inner, class.Metadata(), func(r *omni.MachineClass) error {
+				r.TypedSpec().Value.MatchLabels = []string{"b"}
+
+				return nil
+			})
+			require.NoError(t, err)
+		},
+	}
+
+	st := state.WrapCore(core)
+	r := &applyYamlResource{provider: &omniProvider{state: st}}
+
+	err := r.processResource(t.Context(), st, omni.NewMachineClass(resources.DefaultNamespace, "workers"), nil, ModeCreateOrUpdate)
+	require.ErrorContains(t, err, "failed to update resource 'workers'")
+}
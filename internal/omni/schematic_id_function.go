@@ -0,0 +1,169 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+// factorySchematic mirrors the schematic of the Image Factory, the field order and the YAML tags define its ID.
+type factorySchematic struct {
+	Overlay       factoryOverlay       `yaml:"overlay,omitempty"`
+	Customization factoryCustomization `yaml:"customization"`
+}
+
+type factoryOverlay struct {
+	Image   string         `yaml:"image,omitempty"`
+	Name    string         `yaml:"name,omitempty"`
+	Options map[string]any `yaml:"options,omitempty"`
+}
+
+type factoryCustomization struct {
+	ExtraKernelArgs  []string                `yaml:"extraKernelArgs,omitempty"`
+	Meta             []factoryMetaValue      `yaml:"meta,omitempty"`
+	SystemExtensions factorySystemExtensions `yaml:"systemExtensions,omitempty"`
+}
+
+type factoryMetaValue struct {
+	Key   uint8  `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+type factorySystemExtensions struct {
+	OfficialExtensions []string `yaml:"officialExtensions,omitempty"`
+}
+
+// ID returns the ID the Image Factory assigns to the schematic, the SHA-256 of its YAML.
+func (s *factorySchematic) ID() (string, error) {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the schematic: %w", err)
+	}
+
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+var _ function.Function = &schematicIDFunction{}
+
+func NewSchematicIDFunction() function.Function {
+	return &schematicIDFunction{}
+}
+
+type schematicIDFunction struct{}
+
+func (f *schematicIDFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "schematic_id"
+}
+
+func (f *schematicIDFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Compute the ID of an Image Factory schematic",
+		MarkdownDescription: "Computes the ID the Image Factory assigns to a schematic without calling Omni. The ID depends on the order of " +
+			"the extensions and the kernel arguments, the META values are ordered by key. The schematics created by Omni, like the ones " +
+			"of `omni_schematic`, can contain the SideroLink kernel arguments added by Omni, pass them in `kernel_args` to get the same ID.",
+		Parameters: []function.Parameter{
+			function.ListParameter{
+				Name:                "extensions",
+				MarkdownDescription: "The official system extensions, e.g. `siderolabs/iscsi-tools`.",
+				ElementType:         types.StringType,
+				AllowNullValue:      true,
+			},
+			function.ListParameter{
+				Name:                "kernel_args",
+				MarkdownDescription: "The extra kernel arguments.",
+				ElementType:         types.StringType,
+				AllowNullValue:      true,
+			},
+			function.MapParameter{
+				Name:                "meta",
+				MarkdownDescription: "The META values, keyed by the META key number.",
+				ElementType:         types.StringType,
+				AllowNullValue:      true,
+			},
+			function.DynamicParameter{
+				Name:                "overlay",
+				MarkdownDescription: "The overlay as an object with the `image`, the `name` and optionally the `options`, e.g. `{ image = \"siderolabs/sbc-raspberrypi\", name = \"rpi_generic\" }`, or null.",
+				AllowNullValue:      true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *schematicIDFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var (
+		extensions, kernelArgs []string
+		metaValues             map[string]string
+		overlayValue           types.Dynamic
+	)
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &extensions, &kernelArgs, &metaValues, &overlayValue))
+	if resp.Error != nil {
+		return
+	}
+
+	parsed, err := parseMetaValues(metaValues)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(2, err.Error())
+		return
+	}
+
+	schematic := factorySchematic{
+		Customization: factoryCustomization{
+			ExtraKernelArgs: kernelArgs,
+			SystemExtensions: factorySystemExtensions{
+				OfficialExtensions: extensions,
+			},
+		},
+	}
+
+	for _, key := range sortedMetaKeys(parsed) {
+		schematic.Customization.Meta = append(schematic.Customization.Meta, factoryMetaValue{Key: uint8(key), Value: parsed[key]})
+	}
+
+	schematic.Overlay, err = schematicOverlay(ctx, overlayValue)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(3, fmt.Sprintf("Invalid overlay: %s", err))
+		return
+	}
+
+	id, err := schematic.ID()
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, id)
+}
+
+// schematicOverlay converts the overlay argument to the overlay of the schematic, null means no overlay.
+func schematicOverlay(ctx context.Context, value types.Dynamic) (factoryOverlay, error) {
+	var overlay factoryOverlay
+
+	if value.IsNull() || value.IsUnderlyingValueNull() {
+		return overlay, nil
+	}
+
+	// the overlay is decoded like the Image Factory decodes it, so unknown fields and wrong types are rejected
+	if err := decodeDynamicValue(ctx, value, &overlay); err != nil {
+		return overlay, err
+	}
+
+	if overlay.Image == "" || overlay.Name == "" {
+		return overlay, errors.New("the image and the name must be set")
+	}
+
+	return overlay, nil
+}
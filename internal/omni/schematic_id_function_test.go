@@ -0,0 +1,102 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func runSchematicID(t *testing.T, extensions, kernelArgs []string, meta map[string]string, overlay attr.Value) (string, *function.FuncError) {
+	t.Helper()
+
+	list := func(values []string) attr.Value {
+		if values == nil {
+			return types.ListNull(types.StringType)
+		}
+
+		return types.ListValueMust(types.StringType, stringValueList(values))
+	}
+
+	metaValue := types.MapNull(types.StringType)
+	if meta != nil {
+		elems := make(map[string]attr.Value, len(meta))
+		for k, v := range meta {
+			elems[k] = types.StringValue(v)
+		}
+
+		metaValue = types.MapValueMust(types.StringType, elems)
+	}
+
+	if overlay == nil {
+		overlay = types.DynamicNull()
+	} else {
+		overlay = types.DynamicValue(overlay)
+	}
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+
+	(&schematicIDFunction{}).Run(t.Context(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{list(extensions), list(kernelArgs), metaValue, overlay}),
+	}, resp)
+
+	result, _ := resp.Result.Value().(types.String)
+
+	return result.ValueString(), resp.Error
+}
+
+func stringValueList(values []string) []attr.Value {
+	out := make([]attr.Value, 0, len(values))
+	for _, v := range values {
+		out = append(out, types.StringValue(v))
+	}
+
+	return out
+}
+
+func TestSchematicIDFunction(t *testing.T) {
+	// the IDs are the ones returned by factory.talos.dev for the same schematics
+	id, funcErr := runSchematicID(t, nil, nil, nil, nil)
+	require.Nil(t, funcErr)
+	require.Equal(t, "376567988ad370138ad8b2698212367b8edcb69b5fd68c80be1f2ec7d603b4ba", id)
+
+	id, funcErr = runSchematicID(t, []string{"siderolabs/iscsi-tools", "siderolabs/util-linux-tools"}, []string{}, map[string]string{}, nil)
+	require.Nil(t, funcErr)
+	require.Equal(t, "613e1592b2da41ae5e265e8789429f22e121aab91cb4deb6bc3c0b6262961245", id)
+
+	id, funcErr = runSchematicID(t, nil, nil, nil, yamlValue(map[string]any{"image": "siderolabs/sbc-raspberrypi", "name": "rpi_generic"}))
+	require.Nil(t, funcErr)
+	require.Equal(t, "ee21ef4a5ef808a9b7484cc0dda0f25075021691c8c09a276591eedb638ea1f9", id)
+}
+
+func TestSchematicIDFunctionMeta(t *testing.T) {
+	schematic := factorySchematic{
+		Customization: factoryCustomization{
+			ExtraKernelArgs: []string{"console=ttyS0"},
+			Meta:            []factoryMetaValue{{Key: 0x0a, Value: "a"}, {Key: 0x0c, Value: "b"}},
+		},
+	}
+
+	expected, err := schematic.ID()
+	require.NoError(t, err)
+
+	id, funcErr := runSchematicID(t, nil, []string{"console=ttyS0"}, map[string]string{"12": "b", "10": "a"}, nil)
+	require.Nil(t, funcErr)
+	require.Equal(t, expected, id)
+}
+
+func TestSchematicIDFunctionErrors(t *testing.T) {
+	_, funcErr := runSchematicID(t, nil, nil, map[string]string{"key": "a"}, nil)
+	require.NotNil(t, funcErr)
+	require.Equal(t, int64(2), *funcErr.FunctionArgument)
+
+	_, funcErr = runSchematicID(t, nil, nil, nil, yamlValue(map[string]any{"image": "siderolabs/sbc-raspberrypi"}))
+	require.NotNil(t, funcErr)
+	require.Contains(t, funcErr.Error(), "the image and the name must be set")
+
+	_, funcErr = runSchematicID(t, nil, nil, nil, yamlValue(map[string]any{"image": "a", "name": "b", "unknown": "c"}))
+	require.NotNil(t, funcErr)
+	require.Equal(t, int64(3), *funcErr.FunctionArgument)
+}
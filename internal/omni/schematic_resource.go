@@ -0,0 +1,245 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/siderolabs/omni/client/pkg/meta"
+)
+
+var (
+	_ resource.Resource               = &schematicResource{}
+	_ resource.ResourceWithModifyPlan = &schematicResource{}
+)
+
+func NewSchematicResource() resource.Resource {
+	return &schematicResource{}
+}
+
+type schematicResource struct {
+	provider *omniProvider
+}
+
+type schematicResourceModel struct {
+	ID                   types.String      `tfsdk:"id"`
+	TalosVersion         types.String      `tfsdk:"talos_version"`
+	MediaID              types.String      `tfsdk:"media_id"`
+	Extensions           []types.String    `tfsdk:"extensions"`
+	ExtraKernelArgs      []types.String    `tfsdk:"extra_kernel_args"`
+	MetaValues           map[string]string `tfsdk:"meta_values"`
+	NetworkConfig        types.String      `tfsdk:"network_config"`
+	SecureBoot           types.Bool        `tfsdk:"secure_boot"`
+	SiderolinkGRPCTunnel types.Bool        `tfsdk:"use_siderolink_grpc_tunnel"`
+
+	SchematicID       types.String `tfsdk:"schematic_id"`
+	PXEUrl            types.String `tfsdk:"pxe_url"`
+	GRPCTunnelEnabled types.Bool   `tfsdk:"grpc_tunnel_enabled"`
+}
+
+func (r *schematicResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_schematic"
+}
+
+func (r *schematicResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	computedString := func(description string) schema.StringAttribute {
+		return schema.StringAttribute{
+			MarkdownDescription: description,
+			Computed:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Creates an Image Factory schematic through Omni once and keeps its ID in the state. " +
+			"Changing any of the inputs creates a new schematic. Destroying the resource only removes it from the state, as schematics are immutable. " +
+			"Overlays and join tokens are not supported yet, the schematic API of the Omni client the provider is built against has neither, " +
+			"so the machines join with the default join token of the Omni instance.",
+		Attributes: map[string]schema.Attribute{
+			"id": computedString("The schematic ID"),
+			"talos_version": schema.StringAttribute{
+				MarkdownDescription: "Talos version",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"media_id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the installation media used to generate the pxe url",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"extensions": schema.ListAttribute{
+				MarkdownDescription: "Extensions",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"extra_kernel_args": schema.ListAttribute{
+				MarkdownDescription: "Extra kernel arguments",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"meta_values": schema.MapAttribute{
+				MarkdownDescription: "META values, keyed by the META key number",
+				Optional:            true,
+				ElementType:         types.StringType,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.Map{
+					metaValuesValidator{},
+				},
+			},
+			"network_config": schema.StringAttribute{
+				MarkdownDescription: fmt.Sprintf("Static network configuration in the Talos platform network config YAML format, stored in META key %d", meta.MetalNetworkPlatformConfig),
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"secure_boot": schema.BoolAttribute{
+				MarkdownDescription: "Enable secure boot",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"use_siderolink_grpc_tunnel": schema.BoolAttribute{
+				MarkdownDescription: "Use Siderolink GRPC tunnel",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"schematic_id": computedString("Generated schematic ID"),
+			"pxe_url":      computedString("Generated pxe url"),
+			"grpc_tunnel_enabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether the SideroLink GRPC tunnel is enabled in the schematic",
+				Computed:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *schematicResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *schematicResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan schematicResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	metaValues, err := schematicMetaValues(plan.MetaValues, plan.NetworkConfig)
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	schematic, err := r.provider.management.CreateSchematic(ctx, &management.CreateSchematicRequest{
+		MetaValues:               metaValues,
+		ExtraKernelArgs:          stringValues(plan.ExtraKernelArgs),
+		Extensions:               stringValues(plan.Extensions),
+		MediaId:                  plan.MediaID.ValueString(),
+		SecureBoot:               plan.SecureBoot.ValueBool(),
+		TalosVersion:             plan.TalosVersion.ValueString(),
+		SiderolinkGrpcTunnelMode: grpcTunnelMode(plan.SiderolinkGRPCTunnel),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Schematic generation failed: %s", err))
+		return
+	}
+
+	plan.ID = types.StringValue(schematic.SchematicId)
+	plan.SchematicID = types.StringValue(schematic.SchematicId)
+	plan.PXEUrl = types.StringValue(schematic.PxeUrl)
+	plan.GRPCTunnelEnabled = types.BoolValue(schematic.GrpcTunnelEnabled)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+// ModifyPlan rejects unknown extensions before a new schematic is planned.
+func (r *schematicResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || !req.Plan.Raw.IsFullyKnown() || r.provider == nil {
+		return
+	}
+
+	var tfState, plan schematicResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Existing schematics are not validated again, the extensions catalog may have changed since
+	if !req.State.Raw.IsNull() && tfState.TalosVersion.Equal(plan.TalosVersion) && slices.Equal(stringValues(tfState.Extensions), stringValues(plan.Extensions)) {
+		return
+	}
+
+	if err := validateExtensions(ctx, r.provider.state, plan.TalosVersion.ValueString(), stringValues(plan.Extensions)); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("extensions"), errValidationFailed, err.Error())
+	}
+}
+
+// Read keeps the state as is, schematics are content addressed and never change.
+func (r *schematicResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+}
+
+// Update is never called with changes, as every input requires replacement.
+func (r *schematicResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan schematicResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *schematicResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
@@ -0,0 +1,52 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchematicResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "omni_schematic" "test" {
+  talos_version = "1.9.0"
+  extensions    = ["siderolabs/qemu-guest-agent"]
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("omni_schematic.test", "schematic_id"),
+					resource.TestCheckResourceAttrPair("omni_schematic.test", "id", "omni_schematic.test", "schematic_id"),
+				),
+			},
+			{
+				// Changing an input creates a new schematic
+				Config: providerConfig + `
+resource "omni_schematic" "test" {
+  talos_version     = "1.9.0"
+  extensions        = ["siderolabs/qemu-guest-agent"]
+  extra_kernel_args = ["console=ttyS0"]
+}
+`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("omni_schematic.test", plancheck.ResourceActionReplace),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestGRPCTunnelMode(t *testing.T) {
+	require.Equal(t, management.CreateSchematicRequest_AUTO, grpcTunnelMode(types.BoolNull()))
+	require.Equal(t, management.CreateSchematicRequest_ENABLED, grpcTunnelMode(types.BoolValue(true)))
+	require.Equal(t, management.CreateSchematicRequest_DISABLED, grpcTunnelMode(types.BoolValue(false)))
+}
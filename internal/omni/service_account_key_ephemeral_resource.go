@@ -0,0 +1,141 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/go-api-signature/pkg/serviceaccount"
+)
+
+const defaultServiceAccountKeyTTL = "1h"
+
+var (
+	_ ephemeral.EphemeralResource              = &serviceAccountKeyEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure = &serviceAccountKeyEphemeralResource{}
+)
+
+func NewServiceAccountKeyEphemeralResource() ephemeral.EphemeralResource {
+	return &serviceAccountKeyEphemeralResource{}
+}
+
+type serviceAccountKeyEphemeralResource struct {
+	provider *omniProvider
+}
+
+type serviceAccountKeyEphemeralResourceModel struct {
+	Name         types.String `tfsdk:"name"`
+	TTL          types.String `tfsdk:"ttl"`
+	PublicKeyID  types.String `tfsdk:"public_key_id"`
+	KeyExpiresAt types.String `tfsdk:"key_expires_at"`
+	Key          types.String `tfsdk:"key"`
+}
+
+func (e *serviceAccountKeyEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_service_account_key"
+}
+
+func (e *serviceAccountKeyEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Issues a short-lived key for an existing Omni service account without storing it in the state. " +
+			"Every run registers a new public key in Omni, which stays valid until `ttl` elapses.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of the service account",
+				Required:            true,
+			},
+			"ttl": schema.StringAttribute{
+				MarkdownDescription: "The lifetime of the key as a Go duration. Defaults to '" + defaultServiceAccountKeyTTL + "'",
+				Optional:            true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"public_key_id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the public key registered in Omni",
+				Computed:            true,
+			},
+			"key_expires_at": schema.StringAttribute{
+				MarkdownDescription: "The RFC3339 timestamp of when the key expires",
+				Computed:            true,
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "The generated base64 key of the service account",
+				Computed:            true,
+				Sensitive:           true,
+			},
+		},
+	}
+}
+
+func (e *serviceAccountKeyEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	e.provider = provider
+}
+
+func (e *serviceAccountKeyEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data serviceAccountKeyEphemeralResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.TTL.IsNull() || data.TTL.ValueString() == "" {
+		data.TTL = types.StringValue(defaultServiceAccountKeyTTL)
+	}
+
+	ttl, err := time.ParseDuration(data.TTL.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("ttl"), errCreationFailed, fmt.Sprintf("Invalid ttl: %v", err))
+		return
+	}
+
+	name := data.Name.ValueString()
+
+	key, armoredPublicKey, err := generateServiceAccountKey(name, ttl)
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	encodedKey, err := serviceaccount.Encode(name, key)
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to encode service account key: %v", err))
+		return
+	}
+
+	createdAt := time.Now()
+
+	publicKeyID, err := e.provider.management.RenewServiceAccount(ctx, name, armoredPublicKey)
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to issue a key for service account '%s': %v", name, err))
+		return
+	}
+
+	data.PublicKeyID = types.StringValue(publicKeyID)
+	data.KeyExpiresAt = types.StringValue(createdAt.Add(ttl).UTC().Format(time.RFC3339))
+	data.Key = types.StringValue(encodedKey)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
@@ -0,0 +1,440 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/go-api-signature/pkg/pgp"
+	"github.com/siderolabs/go-api-signature/pkg/serviceaccount"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/siderolabs/omni/client/pkg/access"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Roles known to Omni
+	roleNone          = "None"
+	roleReader        = "Reader"
+	roleOperator      = "Operator"
+	roleAdmin         = "Admin"
+	roleInfraProvider = "InfraProvider"
+
+	defaultServiceAccountTTL = "8760h"
+	serviceAccountKeyComment = "terraform-provider-omni"
+)
+
+var (
+	_ resource.Resource                = &serviceAccountResource{}
+	_ resource.ResourceWithModifyPlan  = &serviceAccountResource{}
+	_ resource.ResourceWithImportState = &serviceAccountResource{}
+)
+
+func NewServiceAccountResource() resource.Resource {
+	return &serviceAccountResource{}
+}
+
+type serviceAccountResource struct {
+	provider *omniProvider
+}
+
+type serviceAccountResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	Name         types.String `tfsdk:"name"`
+	Role         types.String `tfsdk:"role"`
+	TTL          types.String `tfsdk:"ttl"`
+	RotationDays types.Int64  `tfsdk:"rotation_days"`
+	PublicKeyID  types.String `tfsdk:"public_key_id"`
+	KeyCreatedAt types.String `tfsdk:"key_created_at"`
+	KeyExpiresAt types.String `tfsdk:"key_expires_at"`
+	Key          types.String `tfsdk:"key"`
+}
+
+func (r *serviceAccountResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_service_account"
+}
+
+func (r *serviceAccountResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	keyPlanModifiers := []planmodifier.String{
+		stringplanmodifier.UseStateForUnknown(),
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages an Omni service account and its key. " +
+			"The key is renewed when `ttl` changes, when the key has expired or is no longer registered, or when `rotation_days` have passed since it was created. " +
+			"Renewing registers a new public key in Omni; previously issued keys stay valid until they expire.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The name of the service account",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of the service account. Prefix it with `infra-provider:` to create an infra provider service account",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"role": schema.StringAttribute{
+				MarkdownDescription: "The role of the service account ('None', 'Reader', 'Operator', 'Admin' or 'InfraProvider'). " +
+					"If not set, the role of the service account used by the provider is assigned",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(roleNone, roleReader, roleOperator, roleAdmin, roleInfraProvider),
+				},
+			},
+			"ttl": schema.StringAttribute{
+				MarkdownDescription: "The lifetime of the key as a Go duration, e.g. '720h'. Defaults to '" + defaultServiceAccountTTL + "'",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(defaultServiceAccountTTL),
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"rotation_days": schema.Int64Attribute{
+				MarkdownDescription: "Renew the key on the next plan once it is older than this number of days",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"public_key_id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the public key registered in Omni",
+				Computed:            true,
+				PlanModifiers:       keyPlanModifiers,
+			},
+			"key_created_at": schema.StringAttribute{
+				MarkdownDescription: "The RFC3339 timestamp of when the key was created",
+				Computed:            true,
+				PlanModifiers:       keyPlanModifiers,
+			},
+			"key_expires_at": schema.StringAttribute{
+				MarkdownDescription: "The RFC3339 timestamp of when the key expires",
+				Computed:            true,
+				PlanModifiers:       keyPlanModifiers,
+			},
+			"key": schema.StringAttribute{
+				MarkdownDescription: "The generated base64 key of the service account. Use the `omni_service_account_key` ephemeral resource to avoid storing a key in the state",
+				Computed:            true,
+				Sensitive:           true,
+				PlanModifiers:       keyPlanModifiers,
+			},
+		},
+	}
+}
+
+func (r *serviceAccountResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *serviceAccountResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on create or destroy
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var tfState, plan serviceAccountResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !serviceAccountKeyNeedsRenewal(tfState, plan, time.Now()) {
+		return
+	}
+
+	plan.PublicKeyID = types.StringUnknown()
+	plan.KeyCreatedAt = types.StringUnknown()
+	plan.KeyExpiresAt = types.StringUnknown()
+	plan.Key = types.StringUnknown()
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+}
+
+func (r *serviceAccountResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan serviceAccountResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ttl, err := time.ParseDuration(plan.TTL.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("ttl"), errCreationFailed, fmt.Sprintf("Invalid ttl: %v", err))
+		return
+	}
+
+	name := plan.Name.ValueString()
+
+	key, armoredPublicKey, err := generateServiceAccountKey(name, ttl)
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, err.Error())
+		return
+	}
+
+	encodedKey, err := serviceaccount.Encode(name, key)
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to encode service account key: %v", err))
+		return
+	}
+
+	createdAt := time.Now()
+
+	publicKeyID, err := r.provider.management.CreateServiceAccount(ctx, name, armoredPublicKey, plan.Role.ValueString(), plan.Role.IsUnknown() || plan.Role.IsNull())
+	if err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to create service account '%s': %v", name, err))
+		return
+	}
+
+	plan.ID = types.StringValue(name)
+	plan.PublicKeyID = types.StringValue(publicKeyID)
+	plan.KeyCreatedAt = types.StringValue(createdAt.UTC().Format(time.RFC3339))
+	plan.KeyExpiresAt = types.StringValue(createdAt.Add(ttl).UTC().Format(time.RFC3339))
+	plan.Key = types.StringValue(encodedKey)
+	plan.Role = types.StringValue(plan.Role.ValueString())
+
+	// The account exists from here on, the state is saved before the lookup so its key is never lost
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sa, err := r.findServiceAccount(ctx, name)
+	if err != nil {
+		resp.Diagnostics.AddWarning(errStateError, fmt.Sprintf("Service account '%s' was created, but its role could not be read, it is refreshed on the next plan: %v", name, err))
+		return
+	}
+
+	if sa != nil {
+		plan.Role = types.StringValue(sa.GetRole())
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *serviceAccountResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var tfState serviceAccountResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sa, err := r.findServiceAccount(ctx, tfState.ID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(errStateError, err.Error())
+		return
+	}
+
+	if sa == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	tfState.Name = types.StringValue(sa.GetName())
+	tfState.Role = types.StringValue(sa.GetRole())
+
+	// Forget the key if it is no longer registered, so the next plan renews it
+	registered := false
+	for _, publicKey := range sa.GetPgpPublicKeys() {
+		if publicKey.GetId() == tfState.PublicKeyID.ValueString() {
+			registered = true
+			tfState.KeyExpiresAt = types.StringValue(publicKey.GetExpiration().AsTime().UTC().Format(time.RFC3339))
+			break
+		}
+	}
+
+	if !registered {
+		tfState.PublicKeyID = types.StringValue("")
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, tfState)...)
+}
+
+func (r *serviceAccountResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan serviceAccountResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Key.IsUnknown() {
+		ttl, err := time.ParseDuration(plan.TTL.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("ttl"), errUpdateFailed, fmt.Sprintf("Invalid ttl: %v", err))
+			return
+		}
+
+		name := plan.Name.ValueString()
+
+		key, armoredPublicKey, err := generateServiceAccountKey(name, ttl)
+		if err != nil {
+			resp.Diagnostics.AddError(errUpdateFailed, err.Error())
+			return
+		}
+
+		encodedKey, err := serviceaccount.Encode(name, key)
+		if err != nil {
+			resp.Diagnostics.AddError(errUpdateFailed, fmt.Sprintf("Failed to encode service account key: %v", err))
+			return
+		}
+
+		createdAt := time.Now()
+
+		publicKeyID, err := r.provider.management.RenewServiceAccount(ctx, name, armoredPublicKey)
+		if err != nil {
+			resp.Diagnostics.AddError(errUpdateFailed, fmt.Sprintf("Failed to renew service account '%s': %v", name, err))
+			return
+		}
+
+		plan.PublicKeyID = types.StringValue(publicKeyID)
+		plan.KeyCreatedAt = types.StringValue(createdAt.UTC().Format(time.RFC3339))
+		plan.KeyExpiresAt = types.StringValue(createdAt.Add(ttl).UTC().Format(time.RFC3339))
+		plan.Key = types.StringValue(encodedKey)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *serviceAccountResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var tfState serviceAccountResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.provider.management.DestroyServiceAccount(ctx, tfState.ID.ValueString()); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return // Service account already deleted
+		}
+
+		resp.Diagnostics.AddError(errDeleteFailed, fmt.Sprintf("Failed to delete service account '%s': %v", tfState.ID.ValueString(), err))
+	}
+}
+
+func (r *serviceAccountResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), req.ID)...)
+}
+
+func (r *serviceAccountResource) findServiceAccount(ctx context.Context, name string) (*management.ListServiceAccountsResponse_ServiceAccount, error) {
+	serviceAccounts, err := r.provider.management.ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %v", err)
+	}
+
+	for _, sa := range serviceAccounts {
+		if sa.GetName() == name {
+			return sa, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Helper functions
+func generateServiceAccountKey(name string, ttl time.Duration) (*pgp.Key, string, error) {
+	sa := access.ParseServiceAccountFromName(name)
+
+	key, err := pgp.GenerateKey(sa.BaseName, serviceAccountKeyComment, sa.FullID(), ttl)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate service account key: %v", err)
+	}
+
+	armoredPublicKey, err := key.ArmorPublic()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to armor service account public key: %v", err)
+	}
+
+	return key, armoredPublicKey, nil
+}
+
+// serviceAccountKeyNeedsRenewal reports whether the planned service account needs a new key:
+// the ttl changed, the key is no longer registered or expired, or it is older than rotation_days.
+func serviceAccountKeyNeedsRenewal(tfState, plan serviceAccountResourceModel, now time.Time) bool {
+	if !plan.TTL.Equal(tfState.TTL) {
+		return true
+	}
+
+	if tfState.PublicKeyID.ValueString() == "" || tfState.Key.IsNull() {
+		return true
+	}
+
+	if expiresAt, err := time.Parse(time.RFC3339, tfState.KeyExpiresAt.ValueString()); err == nil && !now.Before(expiresAt) {
+		return true
+	}
+
+	if plan.RotationDays.IsNull() || plan.RotationDays.IsUnknown() {
+		return false
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, tfState.KeyCreatedAt.ValueString())
+	if err != nil {
+		return true
+	}
+
+	return !now.Before(createdAt.AddDate(0, 0, int(plan.RotationDays.ValueInt64())))
+}
+
+// durationValidator checks that a string attribute is a valid positive Go duration.
+type durationValidator struct{}
+
+func (v durationValidator) Description(_ context.Context) string {
+	return "value must be a positive duration, e.g. '720h'"
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	d, err := time.ParseDuration(req.ConfigValue.ValueString())
+	if err != nil || d <= 0 {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Duration",
+			fmt.Sprintf("Attribute %s %s, got: %s", req.Path, v.Description(ctx), req.ConfigValue.ValueString()),
+		)
+	}
+}
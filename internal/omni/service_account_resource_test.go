@@ -0,0 +1,118 @@
+package omni
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccountResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// Create new service account
+				Config: providerConfig + `
+resource "omni_service_account" "test" {
+  name = "test-terraform-sa"
+  role = "Reader"
+  ttl  = "24h"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_service_account.test", "id", "test-terraform-sa"),
+					resource.TestCheckResourceAttr("omni_service_account.test", "role", "Reader"),
+					resource.TestCheckResourceAttrSet("omni_service_account.test", "public_key_id"),
+					resource.TestCheckResourceAttrSet("omni_service_account.test", "key"),
+				),
+			},
+			{
+				// Changing the ttl renews the key
+				Config: providerConfig + `
+resource "omni_service_account" "test" {
+  name          = "test-terraform-sa"
+  role          = "Reader"
+  ttl           = "48h"
+  rotation_days = 1
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_service_account.test", "ttl", "48h"),
+					resource.TestCheckResourceAttr("omni_service_account.test", "rotation_days", "1"),
+					resource.TestCheckResourceAttrSet("omni_service_account.test", "key"),
+				),
+			},
+		},
+	})
+}
+
+func TestServiceAccountKeyNeedsRenewal(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tfState := serviceAccountResourceModel{
+		TTL:          types.StringValue("720h"),
+		PublicKeyID:  types.StringValue("key-id"),
+		KeyCreatedAt: types.StringValue(now.AddDate(0, 0, -10).Format(time.RFC3339)),
+		KeyExpiresAt: types.StringValue(now.AddDate(0, 0, 20).Format(time.RFC3339)),
+		Key:          types.StringValue("key"),
+	}
+
+	plan := tfState
+	plan.RotationDays = types.Int64Null()
+	require.False(t, serviceAccountKeyNeedsRenewal(tfState, plan, now), "key without rotation should be kept")
+
+	plan.RotationDays = types.Int64Value(30)
+	require.False(t, serviceAccountKeyNeedsRenewal(tfState, plan, now), "key younger than rotation_days should be kept")
+
+	plan.RotationDays = types.Int64Value(10)
+	require.True(t, serviceAccountKeyNeedsRenewal(tfState, plan, now), "key older than rotation_days should be renewed")
+
+	plan = tfState
+	plan.TTL = types.StringValue("24h")
+	require.True(t, serviceAccountKeyNeedsRenewal(tfState, plan, now), "ttl change should renew the key")
+
+	expired := tfState
+	expired.KeyExpiresAt = types.StringValue(now.Add(-time.Hour).Format(time.RFC3339))
+	require.True(t, serviceAccountKeyNeedsRenewal(expired, expired, now), "expired key should be renewed")
+
+	unregistered := tfState
+	unregistered.PublicKeyID = types.StringValue("")
+	require.True(t, serviceAccountKeyNeedsRenewal(unregistered, unregistered, now), "unregistered key should be renewed")
+
+	imported := tfState
+	imported.Key = types.StringNull()
+	require.True(t, serviceAccountKeyNeedsRenewal(imported, imported, now), "imported service account should get a key")
+}
+
+func TestServiceAccountCreateKeepsStateWhenLookupFails(t *testing.T) {
+	r := &serviceAccountResource{provider: &omniProvider{management: &fakeManagement{
+		createServiceAccount: func(string, string, string, bool) (string, error) {
+			return "public-key-id", nil
+		},
+	}}}
+
+	resp := createWithPlan(t, r, serviceAccountResourceModel{
+		ID:           types.StringUnknown(),
+		Name:         types.StringValue("test-sa"),
+		Role:         types.StringValue("Reader"),
+		TTL:          types.StringValue("24h"),
+		RotationDays: types.Int64Null(),
+		PublicKeyID:  types.StringUnknown(),
+		KeyCreatedAt: types.StringUnknown(),
+		KeyExpiresAt: types.StringUnknown(),
+		Key:          types.StringUnknown(),
+	})
+	require.False(t, resp.Diagnostics.HasError(), resp.Diagnostics)
+	require.Len(t, resp.Diagnostics.Warnings(), 1)
+
+	var saved serviceAccountResourceModel
+
+	require.False(t, resp.State.Get(t.Context(), &saved).HasError())
+	require.Equal(t, "test-sa", saved.ID.ValueString())
+	require.Equal(t, "public-key-id", saved.PublicKeyID.ValueString())
+	require.Equal(t, "Reader", saved.Role.ValueString())
+	require.NotEmpty(t, saved.Key.ValueString())
+}
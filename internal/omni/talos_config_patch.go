@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/siderolabs/talos/pkg/machinery/config/configpatcher"
+	"gopkg.in/yaml.v3"
+)
+
+// talosMachinePatch renders a Talos strategic merge patch setting the given fields of the machine config,
+// e.g. "install" to a *v1alpha1.InstallConfig.
+//
+// The fields are encoded with the Talos config types without the unset values, and the patch is loaded
+// by the Talos machinery, so a patch which Omni would reject fails here.
+func talosMachinePatch(fields map[string]any) (string, error) {
+	machine := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		var node yaml.Node
+
+		if err := node.Encode(fields[key]); err != nil {
+			return "", fmt.Errorf("failed to encode machine.%s: %w", key, err)
+		}
+
+		if withoutNulls(&node) {
+			continue
+		}
+
+		machine.Content = append(machine.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &node)
+	}
+
+	if len(machine.Content) == 0 {
+		return "", errors.New("the patch does not set any field")
+	}
+
+	out, err := yaml.Marshal(&yaml.Node{
+		Kind:    yaml.MappingNode,
+		Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "machine"}, machine},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the patch: %w", err)
+	}
+
+	if _, err = configpatcher.LoadPatch(out); err != nil {
+		return "", fmt.Errorf("the patch is not a valid Talos config patch: %w", err)
+	}
+
+	return string(out), nil
+}
+
+// withoutNulls drops the null values and the mappings left empty from the node, it reports whether the whole node is empty.
+func withoutNulls(node *yaml.Node) bool {
+	switch node.Kind { //nolint:exhaustive
+	case yaml.ScalarNode:
+		return node.Tag == "!!null"
+	case yaml.MappingNode:
+		var kept []*yaml.Node
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !withoutNulls(node.Content[i+1]) {
+				kept = append(kept, node.Content[i], node.Content[i+1])
+			}
+		}
+
+		node.Content = kept
+
+		return len(kept) == 0
+	default:
+		for _, elem := range node.Content {
+			withoutNulls(elem)
+		}
+
+		return false
+	}
+}
+
+// talosErrorMessage flattens the multiple errors returned by the Talos validation into a single line.
+func talosErrorMessage(err error) string {
+	var merr *multierror.Error
+
+	if !errors.As(err, &merr) {
+		return err.Error()
+	}
+
+	messages := make([]string, 0, len(merr.Errors))
+
+	for _, e := range merr.Errors {
+		messages = append(messages, talosErrorMessage(e))
+	}
+
+	return strings.Join(messages, "; ")
+}
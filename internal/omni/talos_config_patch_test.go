@@ -0,0 +1,61 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+// runPatchFunction runs a function returning the YAML of a config patch.
+func runPatchFunction(t *testing.T, f function.Function, args ...attr.Value) (string, *function.FuncError) {
+	t.Helper()
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+
+	f.Run(t.Context(), function.RunRequest{Arguments: function.NewArgumentsData(args)}, resp)
+
+	result, _ := resp.Result.Value().(types.String)
+
+	return result.ValueString(), resp.Error
+}
+
+func stringMapValue(t *testing.T, m map[string]string) attr.Value {
+	t.Helper()
+
+	if m == nil {
+		return types.MapNull(types.StringType)
+	}
+
+	value, diags := types.MapValueFrom(t.Context(), types.StringType, m)
+	require.False(t, diags.HasError())
+
+	return value
+}
+
+func TestTalosMachinePatch(t *testing.T) {
+	wipe := true
+
+	patch, err := talosMachinePatch(map[string]any{
+		"install": &v1alpha1.InstallConfig{InstallDisk: "/dev/sda", InstallWipe: &wipe},
+		"kubelet": &v1alpha1.KubeletConfig{},
+		"sysctls": map[string]string{"vm.max_map_count": "262144"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `machine:
+    install:
+        disk: /dev/sda
+        wipe: true
+    sysctls:
+        vm.max_map_count: "262144"
+`, patch)
+
+	_, err = talosMachinePatch(map[string]any{"kubelet": &v1alpha1.KubeletConfig{}})
+	require.ErrorContains(t, err, "the patch does not set any field")
+
+	_, err = talosMachinePatch(map[string]any{"unknown": "value"})
+	require.ErrorContains(t, err, "the patch is not a valid Talos config patch")
+}
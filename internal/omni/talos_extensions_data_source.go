@@ -0,0 +1,251 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var _ datasource.DataSource = &talosExtensionsDataSource{}
+
+func NewTalosExtensionsDataSource() datasource.DataSource {
+	return &talosExtensionsDataSource{}
+}
+
+type talosExtensionsDataSource struct {
+	provider *omniProvider
+}
+
+type talosExtensionsDataSourceModel struct {
+	TalosVersion types.String          `tfsdk:"talos_version"`
+	Extensions   []talosExtensionModel `tfsdk:"extensions"`
+}
+
+type talosExtensionModel struct {
+	Name        types.String `tfsdk:"name"`
+	Version     types.String `tfsdk:"version"`
+	Author      types.String `tfsdk:"author"`
+	Description types.String `tfsdk:"description"`
+	Ref         types.String `tfsdk:"ref"`
+	Digest      types.String `tfsdk:"digest"`
+}
+
+func (d *talosExtensionsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_talos_extensions"
+}
+
+func (d *talosExtensionsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	computedString := func(description string) schema.StringAttribute {
+		return schema.StringAttribute{
+			MarkdownDescription: description,
+			Computed:            true,
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "List the official system extensions available for a Talos version",
+		Attributes: map[string]schema.Attribute{
+			"talos_version": schema.StringAttribute{
+				MarkdownDescription: "Talos version",
+				Required:            true,
+			},
+			"extensions": schema.ListNestedAttribute{
+				MarkdownDescription: "List of extensions",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name":        computedString("Extension name, e.g. 'siderolabs/qemu-guest-agent'"),
+						"version":     computedString("Extension version"),
+						"author":      computedString("Extension author"),
+						"description": computedString("Extension description"),
+						"ref":         computedString("Extension image reference"),
+						"digest":      computedString("Extension image digest"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *talosExtensionsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *talosExtensionsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data talosExtensionsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	extensions, err := getTalosExtensions(ctx, d.provider.state, data.TalosVersion.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to Get Extensions", err.Error())
+		return
+	}
+
+	data.Extensions = []talosExtensionModel{}
+	for _, item := range extensions.TypedSpec().Value.Items {
+		data.Extensions = append(data.Extensions, talosExtensionModel{
+			Name:        types.StringValue(item.Name),
+			Version:     types.StringValue(item.Version),
+			Author:      types.StringValue(item.Author),
+			Description: types.StringValue(item.Description),
+			Ref:         types.StringValue(item.Ref),
+			Digest:      types.StringValue(item.Digest),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Helper functions
+func getTalosExtensions(ctx context.Context, st state.State, talosVersion string) (*omni.TalosExtensions, error) {
+	extensions, err := safe.StateGetByID[*omni.TalosExtensions](ctx, st, strings.TrimPrefix(talosVersion, "v"))
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			return nil, fmt.Errorf("no extensions found for Talos version '%s'", talosVersion)
+		}
+
+		return nil, fmt.Errorf("failed to get extensions for Talos version '%s': %w", talosVersion, err)
+	}
+
+	return extensions, nil
+}
+
+// validateExtensions checks that all the requested extensions are available for the Talos version.
+func validateExtensions(ctx context.Context, st state.State, talosVersion string, requested []string) error {
+	if len(requested) == 0 {
+		return nil
+	}
+
+	extensions, err := getTalosExtensions(ctx, st, talosVersion)
+	if err != nil {
+		return err
+	}
+
+	available := make([]string, 0, len(extensions.TypedSpec().Value.Items))
+	for _, item := range extensions.TypedSpec().Value.Items {
+		available = append(available, item.Name)
+	}
+
+	return checkExtensionNames(talosVersion, requested, available)
+}
+
+func checkExtensionNames(talosVersion string, requested, available []string) error {
+	known := make(map[string]struct{}, len(available))
+	for _, name := range available {
+		known[name] = struct{}{}
+	}
+
+	var problems []string
+
+	for _, name := range requested {
+		if _, ok := known[name]; ok {
+			continue
+		}
+
+		problem := fmt.Sprintf("unknown extension '%s'", name)
+		if suggestion := suggestExtension(name, available); suggestion != "" {
+			problem += fmt.Sprintf(", did you mean '%s'?", suggestion)
+		}
+
+		problems = append(problems, problem)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid extensions for Talos version '%s': %s", talosVersion, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// suggestExtension returns the available extension closest to the given name, or an empty string if none is close enough.
+// Extensions containing the name are preferred, otherwise the one with the smallest edit distance is picked.
+func suggestExtension(name string, available []string) string {
+	name = strings.ToLower(name)
+
+	contains := ""
+	closest := ""
+	closestDistance := -1
+
+	for _, candidate := range available {
+		lower := strings.ToLower(candidate)
+		short := lower[strings.LastIndex(lower, "/")+1:]
+
+		if short == name {
+			return candidate
+		}
+
+		if strings.Contains(lower, name) && (contains == "" || len(candidate) < len(contains)) {
+			contains = candidate
+		}
+
+		distance := min(levenshtein(name, lower), levenshtein(name, short))
+		if closestDistance == -1 || distance < closestDistance {
+			closest, closestDistance = candidate, distance
+		}
+	}
+
+	if contains != "" {
+		return contains
+	}
+
+	if closestDistance == -1 || closestDistance > max(2, len(name)/3) {
+		return ""
+	}
+
+	return closest
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,48 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTalosExtensionsDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "omni_talos_extensions" "v195" {
+  talos_version = "v1.9.5"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.omni_talos_extensions.v195", "extensions.0.name"),
+					resource.TestCheckResourceAttrSet("data.omni_talos_extensions.v195", "extensions.0.digest"),
+				),
+			},
+		},
+	})
+}
+
+func TestCheckExtensionNames(t *testing.T) {
+	available := []string{"siderolabs/iscsi-tools", "siderolabs/qemu-guest-agent", "siderolabs/nvidia-container-toolkit-lts"}
+
+	require.NoError(t, checkExtensionNames("1.9.5", []string{"siderolabs/iscsi-tools"}, available))
+
+	err := checkExtensionNames("1.9.5", []string{"iscsi-tools", "siderolabs/qemu-guest-agnt", "k8s"}, available)
+	require.EqualError(t, err, "invalid extensions for Talos version '1.9.5': "+
+		"unknown extension 'iscsi-tools', did you mean 'siderolabs/iscsi-tools'?; "+
+		"unknown extension 'siderolabs/qemu-guest-agnt', did you mean 'siderolabs/qemu-guest-agent'?; "+
+		"unknown extension 'k8s'")
+}
+
+func TestSuggestExtension(t *testing.T) {
+	available := []string{"siderolabs/iscsi-tools", "siderolabs/qemu-guest-agent", "siderolabs/nvidia-container-toolkit-lts"}
+
+	require.Equal(t, "siderolabs/qemu-guest-agent", suggestExtension("qemu", available[:2]))
+	require.Equal(t, "siderolabs/iscsi-tools", suggestExtension("ISCSI-TOOLS", available))
+	require.Empty(t, suggestExtension("docker", available))
+	require.Empty(t, suggestExtension("anything", nil))
+}
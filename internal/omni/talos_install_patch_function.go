@@ -0,0 +1,85 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/v1alpha1"
+)
+
+var _ function.Function = &talosInstallPatchFunction{}
+
+func NewTalosInstallPatchFunction() function.Function {
+	return &talosInstallPatchFunction{}
+}
+
+type talosInstallPatchFunction struct{}
+
+func (f *talosInstallPatchFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "talos_install_patch"
+}
+
+func (f *talosInstallPatchFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Build a Talos config patch setting the install disk and image",
+		MarkdownDescription: "Returns the YAML of a Talos config patch setting `machine.install.disk` and `machine.install.image`, " +
+			"e.g. for the config patches of `omni_apply_yaml`. At least one of them must be set.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "disk",
+				MarkdownDescription: "The disk to install Talos to, e.g. `/dev/sda`, or null.",
+				AllowNullValue:      true,
+			},
+			function.StringParameter{
+				Name:                "image",
+				MarkdownDescription: "The installer image, e.g. `factory.talos.dev/installer/<schematic id>:v1.9.5`, or null.",
+				AllowNullValue:      true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *talosInstallPatchFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var disk, image types.String
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &disk, &image))
+	if resp.Error != nil {
+		return
+	}
+
+	if disk.ValueString() == "" && image.ValueString() == "" {
+		resp.Error = function.NewFuncError("At least one of the disk and the image must be set")
+		return
+	}
+
+	install := &v1alpha1.InstallConfig{
+		InstallDisk:  disk.ValueString(),
+		InstallImage: image.ValueString(),
+	}
+
+	if install.InstallDisk != "" && !strings.HasPrefix(install.InstallDisk, "/dev/") {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("The install disk %q must be a device path like /dev/sda", install.InstallDisk))
+		return
+	}
+
+	if strings.ContainsAny(install.InstallImage, " \t\n") || strings.Contains(install.InstallImage, "://") {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("The install image %q must be an image reference like ghcr.io/siderolabs/installer:v1.9.5", install.InstallImage))
+		return
+	}
+
+	patch, err := talosMachinePatch(map[string]any{"install": install})
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, patch)
+}
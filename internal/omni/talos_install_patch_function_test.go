@@ -0,0 +1,35 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTalosInstallPatchFunction(t *testing.T) {
+	patch, funcErr := runPatchFunction(t, &talosInstallPatchFunction{}, types.StringValue("/dev/nvme0n1"), types.StringValue("factory.talos.dev/installer/376567988ad370138ad8b2698212367b8edcb69b5fd68c80be1f2ec7d603b4ba:v1.9.5"))
+	require.Nil(t, funcErr)
+	require.Equal(t, `machine:
+    install:
+        disk: /dev/nvme0n1
+        image: factory.talos.dev/installer/376567988ad370138ad8b2698212367b8edcb69b5fd68c80be1f2ec7d603b4ba:v1.9.5
+`, patch)
+
+	patch, funcErr = runPatchFunction(t, &talosInstallPatchFunction{}, types.StringValue("/dev/sda"), types.StringNull())
+	require.Nil(t, funcErr)
+	require.Equal(t, "machine:\n    install:\n        disk: /dev/sda\n", patch)
+}
+
+func TestTalosInstallPatchFunctionErrors(t *testing.T) {
+	_, funcErr := runPatchFunction(t, &talosInstallPatchFunction{}, types.StringNull(), types.StringNull())
+	require.NotNil(t, funcErr)
+
+	_, funcErr = runPatchFunction(t, &talosInstallPatchFunction{}, types.StringValue("sda"), types.StringNull())
+	require.NotNil(t, funcErr)
+	require.Equal(t, int64(0), *funcErr.FunctionArgument)
+
+	_, funcErr = runPatchFunction(t, &talosInstallPatchFunction{}, types.StringNull(), types.StringValue("https://ghcr.io/siderolabs/installer"))
+	require.NotNil(t, funcErr)
+	require.Equal(t, int64(1), *funcErr.FunctionArgument)
+}
@@ -0,0 +1,79 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/v1alpha1"
+)
+
+var _ function.Function = &talosKubeletExtraArgsPatchFunction{}
+
+func NewTalosKubeletExtraArgsPatchFunction() function.Function {
+	return &talosKubeletExtraArgsPatchFunction{}
+}
+
+type talosKubeletExtraArgsPatchFunction struct{}
+
+func (f *talosKubeletExtraArgsPatchFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "talos_kubelet_extra_args_patch"
+}
+
+func (f *talosKubeletExtraArgsPatchFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Build a Talos config patch setting extra kubelet arguments",
+		MarkdownDescription: "Returns the YAML of a Talos config patch setting `machine.kubelet.extraArgs`. The arguments are keyed " +
+			"by the flag name without the leading dashes, e.g. `{ \"max-pods\" = \"250\" }`.",
+		Parameters: []function.Parameter{
+			function.MapParameter{
+				Name:                "args",
+				MarkdownDescription: "The extra kubelet arguments.",
+				ElementType:         types.StringType,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *talosKubeletExtraArgsPatchFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var args map[string]string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &args))
+	if resp.Error != nil {
+		return
+	}
+
+	if len(args) == 0 {
+		resp.Error = function.NewArgumentFuncError(0, "At least one kubelet argument must be set")
+		return
+	}
+
+	for key := range args {
+		if key == "" || strings.HasPrefix(key, "-") || strings.ContainsAny(key, "= \t") {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid kubelet argument %q: use the flag name without the leading dashes, e.g. max-pods", key))
+			return
+		}
+	}
+
+	kubelet := &v1alpha1.KubeletConfig{KubeletExtraArgs: args}
+
+	if _, err := kubelet.Validate(); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid kubelet arguments: %s", talosErrorMessage(err)))
+		return
+	}
+
+	patch, err := talosMachinePatch(map[string]any{"kubelet": kubelet})
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, patch)
+}
@@ -0,0 +1,26 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTalosKubeletExtraArgsPatchFunction(t *testing.T) {
+	patch, funcErr := runPatchFunction(t, &talosKubeletExtraArgsPatchFunction{}, stringMapValue(t, map[string]string{
+		"max-pods":                   "250",
+		"rotate-server-certificates": "true",
+	}))
+	require.Nil(t, funcErr)
+	require.Equal(t, `machine:
+    kubelet:
+        extraArgs:
+            max-pods: "250"
+            rotate-server-certificates: "true"
+`, patch)
+
+	for _, args := range []map[string]string{{}, {"--max-pods": "250"}, {"max-pods=250": ""}} {
+		_, funcErr = runPatchFunction(t, &talosKubeletExtraArgsPatchFunction{}, stringMapValue(t, args))
+		require.NotNil(t, funcErr)
+	}
+}
@@ -0,0 +1,120 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/v1alpha1"
+)
+
+var _ function.Function = &talosNetworkInterfacesPatchFunction{}
+
+func NewTalosNetworkInterfacesPatchFunction() function.Function {
+	return &talosNetworkInterfacesPatchFunction{}
+}
+
+type talosNetworkInterfacesPatchFunction struct{}
+
+func (f *talosNetworkInterfacesPatchFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "talos_network_interfaces_patch"
+}
+
+func (f *talosNetworkInterfacesPatchFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Build a Talos config patch configuring network interfaces and VIPs",
+		MarkdownDescription: "Returns the YAML of a Talos config patch setting `machine.network.interfaces`. Every interface is an object " +
+			"with the fields of a Talos network device, e.g. `interface`, `addresses`, `routes`, `mtu`, `dhcp` and `vip = { ip = \"...\" }`, " +
+			"and is validated like Talos validates the machine config.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:                "interfaces",
+				MarkdownDescription: "The list of network interfaces.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *talosNetworkInterfacesPatchFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &value))
+	if resp.Error != nil {
+		return
+	}
+
+	var devices []*v1alpha1.Device
+
+	if err := decodeDynamicValue(ctx, value, &devices); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid network interfaces: %s", err))
+		return
+	}
+
+	if err := validateNetworkDevices(devices); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid network interfaces: %s", talosErrorMessage(err)))
+		return
+	}
+
+	patch, err := talosMachinePatch(map[string]any{"network": &v1alpha1.NetworkConfig{NetworkInterfaces: devices}})
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, patch)
+}
+
+// validateNetworkDevices runs the network device checks of the Talos machine config validation.
+func validateNetworkDevices(devices []*v1alpha1.Device) error {
+	if len(devices) == 0 {
+		return errors.New("at least one interface must be set")
+	}
+
+	// the interfaces of bonds and bridges can not be addressed on their own
+	secondaryInterfaces := map[string]string{}
+
+	for _, device := range devices {
+		if device == nil {
+			continue
+		}
+
+		var links []string
+
+		if device.DeviceBond != nil {
+			links = device.DeviceBond.BondInterfaces
+		}
+
+		if device.DeviceBridge != nil {
+			links = device.DeviceBridge.BridgedInterfaces
+		}
+
+		for _, link := range links {
+			if other, ok := secondaryInterfaces[link]; ok && other != device.DeviceInterface {
+				return fmt.Errorf("interface %q is declared as part of two separate links: %q and %q", link, other, device.DeviceInterface)
+			}
+
+			secondaryInterfaces[link] = device.DeviceInterface
+		}
+	}
+
+	for _, device := range devices {
+		if _, err := v1alpha1.ValidateNetworkDevices(
+			device,
+			secondaryInterfaces,
+			v1alpha1.CheckDeviceInterface,
+			v1alpha1.CheckDeviceAddressing,
+			v1alpha1.CheckDeviceRoutes,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTalosNetworkInterfacesPatchFunction(t *testing.T) {
+	interfaces := yamlValue([]any{
+		map[string]any{
+			"interface": "eth0",
+			"addresses": []any{"10.0.0.2/24"},
+			"routes":    []any{map[string]any{"network": "0.0.0.0/0", "gateway": "10.0.0.1"}},
+			"mtu":       9000,
+			"vip":       map[string]any{"ip": "10.0.0.10"},
+		},
+		map[string]any{
+			"deviceSelector": map[string]any{"hardwareAddr": "00:50:56:*"},
+			"dhcp":           true,
+		},
+	})
+
+	patch, funcErr := runPatchFunction(t, &talosNetworkInterfacesPatchFunction{}, types.DynamicValue(interfaces))
+	require.Nil(t, funcErr)
+	require.Equal(t, `machine:
+    network:
+        interfaces:
+            - interface: eth0
+              addresses:
+                - 10.0.0.2/24
+              routes:
+                - network: 0.0.0.0/0
+                  gateway: 10.0.0.1
+              mtu: 9000
+              vip:
+                ip: 10.0.0.10
+            - deviceSelector:
+                hardwareAddr: 00:50:56:*
+              dhcp: true
+`, patch)
+}
+
+func TestTalosNetworkInterfacesPatchFunctionErrors(t *testing.T) {
+	for name, interfaces := range map[string]any{
+		"unknown field":  []any{map[string]any{"interface": "eth0", "address": "10.0.0.2/24"}},
+		"invalid vip":    []any{map[string]any{"interface": "eth0", "vip": map[string]any{"ip": "10.0.0"}}},
+		"no interface":   []any{map[string]any{"dhcp": true}},
+		"bonded address": []any{map[string]any{"interface": "bond0", "bond": map[string]any{"mode": "active-backup", "interfaces": []any{"eth0"}}}, map[string]any{"interface": "eth0", "dhcp": true}},
+		"no interfaces":  []any{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, funcErr := runPatchFunction(t, &talosNetworkInterfacesPatchFunction{}, types.DynamicValue(yamlValue(interfaces)))
+			require.NotNil(t, funcErr)
+			require.Contains(t, funcErr.Error(), "Invalid network interfaces")
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/talos/pkg/machinery/labels"
+)
+
+var _ function.Function = &talosNodeLabelsPatchFunction{}
+
+func NewTalosNodeLabelsPatchFunction() function.Function {
+	return &talosNodeLabelsPatchFunction{}
+}
+
+type talosNodeLabelsPatchFunction struct{}
+
+func (f *talosNodeLabelsPatchFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "talos_node_labels_patch"
+}
+
+func (f *talosNodeLabelsPatchFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Build a Talos config patch setting the Kubernetes node labels and taints",
+		MarkdownDescription: "Returns the YAML of a Talos config patch setting `machine.nodeLabels` and `machine.nodeTaints`. " +
+			"The labels and the taints are validated like Kubernetes validates them, at least one of them must be set.",
+		Parameters: []function.Parameter{
+			function.MapParameter{
+				Name:                "labels",
+				MarkdownDescription: "The node labels, e.g. `{ \"node.kubernetes.io/role\" = \"storage\" }`, or null.",
+				ElementType:         types.StringType,
+				AllowNullValue:      true,
+			},
+			function.MapParameter{
+				Name:                "taints",
+				MarkdownDescription: "The node taints as `value:effect` or `effect` keyed by the taint key, e.g. `{ \"dedicated\" = \"storage:NoSchedule\" }`, or null.",
+				ElementType:         types.StringType,
+				AllowNullValue:      true,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *talosNodeLabelsPatchFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var nodeLabels, nodeTaints map[string]string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &nodeLabels, &nodeTaints))
+	if resp.Error != nil {
+		return
+	}
+
+	if len(nodeLabels) == 0 && len(nodeTaints) == 0 {
+		resp.Error = function.NewFuncError("At least one of the labels and the taints must be set")
+		return
+	}
+
+	if err := labels.Validate(nodeLabels); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid node labels: %s", talosErrorMessage(err)))
+		return
+	}
+
+	if err := labels.ValidateTaints(nodeTaints); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Invalid node taints: %s", talosErrorMessage(err)))
+		return
+	}
+
+	fields := map[string]any{}
+
+	if len(nodeLabels) > 0 {
+		fields["nodeLabels"] = nodeLabels
+	}
+
+	if len(nodeTaints) > 0 {
+		fields["nodeTaints"] = nodeTaints
+	}
+
+	patch, err := talosMachinePatch(fields)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, patch)
+}
@@ -0,0 +1,39 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTalosNodeLabelsPatchFunction(t *testing.T) {
+	patch, funcErr := runPatchFunction(t, &talosNodeLabelsPatchFunction{},
+		stringMapValue(t, map[string]string{"node.kubernetes.io/role": "storage"}),
+		stringMapValue(t, map[string]string{"dedicated": "storage:NoSchedule"}),
+	)
+	require.Nil(t, funcErr)
+	require.Equal(t, `machine:
+    nodeLabels:
+        node.kubernetes.io/role: storage
+    nodeTaints:
+        dedicated: storage:NoSchedule
+`, patch)
+
+	patch, funcErr = runPatchFunction(t, &talosNodeLabelsPatchFunction{}, stringMapValue(t, nil), stringMapValue(t, map[string]string{"dedicated": "NoExecute"}))
+	require.Nil(t, funcErr)
+	require.Equal(t, "machine:\n    nodeTaints:\n        dedicated: NoExecute\n", patch)
+}
+
+func TestTalosNodeLabelsPatchFunctionErrors(t *testing.T) {
+	_, funcErr := runPatchFunction(t, &talosNodeLabelsPatchFunction{}, stringMapValue(t, nil), stringMapValue(t, nil))
+	require.NotNil(t, funcErr)
+
+	_, funcErr = runPatchFunction(t, &talosNodeLabelsPatchFunction{}, stringMapValue(t, map[string]string{"role": "not valid"}), stringMapValue(t, nil))
+	require.NotNil(t, funcErr)
+	require.Equal(t, int64(0), *funcErr.FunctionArgument)
+
+	_, funcErr = runPatchFunction(t, &talosNodeLabelsPatchFunction{}, stringMapValue(t, nil), stringMapValue(t, map[string]string{"dedicated": "storage:Sometimes"}))
+	require.NotNil(t, funcErr)
+	require.Equal(t, int64(1), *funcErr.FunctionArgument)
+	require.Contains(t, funcErr.Error(), `invalid taint effect: "Sometimes"`)
+}
@@ -0,0 +1,103 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/talos/pkg/machinery/config/types/v1alpha1"
+)
+
+var _ function.Function = &talosRegistryMirrorsPatchFunction{}
+
+func NewTalosRegistryMirrorsPatchFunction() function.Function {
+	return &talosRegistryMirrorsPatchFunction{}
+}
+
+type talosRegistryMirrorsPatchFunction struct{}
+
+func (f *talosRegistryMirrorsPatchFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "talos_registry_mirrors_patch"
+}
+
+func (f *talosRegistryMirrorsPatchFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Build a Talos config patch configuring registry mirrors",
+		MarkdownDescription: "Returns the YAML of a Talos config patch setting `machine.registries.mirrors`. The mirrors are keyed " +
+			"by the registry, e.g. `docker.io` or `*`, every mirror is an object with the `endpoints` and optionally `overridePath` " +
+			"and `skipFallback`. The endpoints must be http or https URLs.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:                "mirrors",
+				MarkdownDescription: "The registry mirrors keyed by registry.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *talosRegistryMirrorsPatchFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value types.Dynamic
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &value))
+	if resp.Error != nil {
+		return
+	}
+
+	var mirrors map[string]*v1alpha1.RegistryMirrorConfig
+
+	if err := decodeDynamicValue(ctx, value, &mirrors); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid registry mirrors: %s", err))
+		return
+	}
+
+	if err := validateRegistryMirrors(mirrors); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid registry mirrors: %s", err))
+		return
+	}
+
+	patch, err := talosMachinePatch(map[string]any{"registries": &v1alpha1.RegistriesConfig{RegistryMirrors: mirrors}})
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, patch)
+}
+
+func validateRegistryMirrors(mirrors map[string]*v1alpha1.RegistryMirrorConfig) error {
+	if len(mirrors) == 0 {
+		return errors.New("at least one mirror must be set")
+	}
+
+	registries := make([]string, 0, len(mirrors))
+	for registry := range mirrors {
+		registries = append(registries, registry)
+	}
+
+	sort.Strings(registries)
+
+	for _, registry := range registries {
+		mirror := mirrors[registry]
+		if mirror == nil || len(mirror.MirrorEndpoints) == 0 {
+			return fmt.Errorf("the mirror of %q has no endpoints", registry)
+		}
+
+		for _, endpoint := range mirror.MirrorEndpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("the endpoint %q of the mirror of %q must be an http or https URL", endpoint, registry)
+			}
+		}
+	}
+
+	return nil
+}
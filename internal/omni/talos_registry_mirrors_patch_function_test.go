@@ -0,0 +1,45 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTalosRegistryMirrorsPatchFunction(t *testing.T) {
+	mirrors := yamlValue(map[string]any{
+		"docker.io": map[string]any{"endpoints": []any{"https://mirror.example.com"}, "overridePath": true},
+		"*":         map[string]any{"endpoints": []any{"http://10.0.0.5:5000"}, "skipFallback": false},
+	})
+
+	patch, funcErr := runPatchFunction(t, &talosRegistryMirrorsPatchFunction{}, types.DynamicValue(mirrors))
+	require.Nil(t, funcErr)
+	require.Equal(t, `machine:
+    registries:
+        mirrors:
+            '*':
+                endpoints:
+                    - http://10.0.0.5:5000
+                skipFallback: false
+            docker.io:
+                endpoints:
+                    - https://mirror.example.com
+                overridePath: true
+`, patch)
+}
+
+func TestTalosRegistryMirrorsPatchFunctionErrors(t *testing.T) {
+	for name, mirrors := range map[string]any{
+		"unknown field":  map[string]any{"docker.io": map[string]any{"endpoint": []any{"https://mirror.example.com"}}},
+		"no endpoints":   map[string]any{"docker.io": map[string]any{"endpoints": []any{}}},
+		"invalid scheme": map[string]any{"docker.io": map[string]any{"endpoints": []any{"oci://mirror.example.com"}}},
+		"no mirrors":     map[string]any{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, funcErr := runPatchFunction(t, &talosRegistryMirrorsPatchFunction{}, types.DynamicValue(yamlValue(mirrors)))
+			require.NotNil(t, funcErr)
+			require.Contains(t, funcErr.Error(), "Invalid registry mirrors")
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// sysctlKeyRegexp matches the kernel parameter names, e.g. net.ipv4.ip_forward or net/ipv4/conf/eth0.100/forwarding.
+var sysctlKeyRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+([./][a-zA-Z0-9_.-]+)*$`)
+
+var _ function.Function = &talosSysctlsPatchFunction{}
+
+func NewTalosSysctlsPatchFunction() function.Function {
+	return &talosSysctlsPatchFunction{}
+}
+
+type talosSysctlsPatchFunction struct{}
+
+func (f *talosSysctlsPatchFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "talos_sysctls_patch"
+}
+
+func (f *talosSysctlsPatchFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Build a Talos config patch setting kernel parameters",
+		MarkdownDescription: "Returns the YAML of a Talos config patch setting `machine.sysctls`, " +
+			"e.g. `{ \"net.ipv4.ip_forward\" = \"1\" }`.",
+		Parameters: []function.Parameter{
+			function.MapParameter{
+				Name:                "sysctls",
+				MarkdownDescription: "The kernel parameters keyed by name.",
+				ElementType:         types.StringType,
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *talosSysctlsPatchFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var sysctls map[string]string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &sysctls))
+	if resp.Error != nil {
+		return
+	}
+
+	if len(sysctls) == 0 {
+		resp.Error = function.NewArgumentFuncError(0, "At least one kernel parameter must be set")
+		return
+	}
+
+	for key := range sysctls {
+		if !sysctlKeyRegexp.MatchString(key) {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid kernel parameter name %q", key))
+			return
+		}
+	}
+
+	patch, err := talosMachinePatch(map[string]any{"sysctls": sysctls})
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, patch)
+}
@@ -0,0 +1,25 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTalosSysctlsPatchFunction(t *testing.T) {
+	patch, funcErr := runPatchFunction(t, &talosSysctlsPatchFunction{}, stringMapValue(t, map[string]string{
+		"net.ipv4.ip_forward":               "1",
+		"net/ipv4/conf/eth0.100/forwarding": "1",
+	}))
+	require.Nil(t, funcErr)
+	require.Equal(t, `machine:
+    sysctls:
+        net.ipv4.ip_forward: "1"
+        net/ipv4/conf/eth0.100/forwarding: "1"
+`, patch)
+
+	for _, sysctls := range []map[string]string{{}, {"net.ipv4 ip_forward": "1"}, {".net": "1"}} {
+		_, funcErr = runPatchFunction(t, &talosSysctlsPatchFunction{}, stringMapValue(t, sysctls))
+		require.NotNil(t, funcErr)
+	}
+}
@@ -0,0 +1,257 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+)
+
+var _ datasource.DataSource = &talosVersionsDataSource{}
+
+func NewTalosVersionsDataSource() datasource.DataSource {
+	return &talosVersionsDataSource{}
+}
+
+type talosVersionsDataSource struct {
+	provider *omniProvider
+}
+
+type talosVersionsDataSourceModel struct {
+	Constraint        types.String        `tfsdk:"constraint"`
+	IncludeDeprecated types.Bool          `tfsdk:"include_deprecated"`
+	Versions          []talosVersionModel `tfsdk:"versions"`
+	Latest            types.String        `tfsdk:"latest"`
+}
+
+type talosVersionModel struct {
+	Version                      types.String   `tfsdk:"version"`
+	CompatibleKubernetesVersions []types.String `tfsdk:"compatible_kubernetes_versions"`
+	Deprecated                   types.Bool     `tfsdk:"deprecated"`
+}
+
+func (d *talosVersionsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_talos_versions"
+}
+
+func (d *talosVersionsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "List the Talos versions available in Omni and the Kubernetes versions each of them supports",
+		Attributes: map[string]schema.Attribute{
+			"constraint": schema.StringAttribute{
+				MarkdownDescription: "Only list the versions matching this version constraint, e.g. '~> 1.9'",
+				Optional:            true,
+				Validators: []validator.String{
+					versionConstraintValidator{},
+				},
+			},
+			"include_deprecated": schema.BoolAttribute{
+				MarkdownDescription: "Also list deprecated versions. Deprecated versions are never returned as `latest`",
+				Optional:            true,
+			},
+			"versions": schema.ListNestedAttribute{
+				MarkdownDescription: "List of Talos versions sorted from oldest to newest",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"version": schema.StringAttribute{
+							MarkdownDescription: "Talos version",
+							Computed:            true,
+						},
+						"compatible_kubernetes_versions": schema.ListAttribute{
+							MarkdownDescription: "Kubernetes versions supported by this Talos version",
+							Computed:            true,
+							ElementType:         types.StringType,
+						},
+						"deprecated": schema.BoolAttribute{
+							MarkdownDescription: "Whether the version is deprecated",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"latest": schema.StringAttribute{
+				MarkdownDescription: "The newest non deprecated Talos version matching the constraint. Prereleases are only returned when the constraint includes a prerelease, e.g. `>= 1.10.0-alpha.0`",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *talosVersionsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	d.provider = provider
+}
+
+func (d *talosVersionsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data talosVersionsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	st := d.provider.state
+
+	talosVersions, err := safe.StateList[*omni.TalosVersion](ctx, st, omni.NewTalosVersion(resources.DefaultNamespace, "").Metadata())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to Get Talos Versions",
+			fmt.Sprintf("Failed to get Talos versions from Omni: %s", err),
+		)
+		return
+	}
+
+	specs := map[string]*omni.TalosVersion{}
+	versions := make([]string, 0, talosVersions.Len())
+	for item := range talosVersions.All() {
+		if item.TypedSpec().Value.Deprecated && !data.IncludeDeprecated.ValueBool() {
+			continue
+		}
+
+		specs[item.TypedSpec().Value.Version] = item
+		versions = append(versions, item.TypedSpec().Value.Version)
+	}
+
+	matching, err := matchingVersions(versions, data.Constraint.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid Version Constraint", err.Error())
+		return
+	}
+
+	data.Versions = []talosVersionModel{}
+	for _, v := range matching {
+		spec := specs[v].TypedSpec().Value
+
+		data.Versions = append(data.Versions, talosVersionModel{
+			Version:                      types.StringValue(v),
+			CompatibleKubernetesVersions: stringList(spec.CompatibleKubernetesVersions),
+			Deprecated:                   types.BoolValue(spec.Deprecated),
+		})
+	}
+
+	data.Latest = latestVersion(matching, data.Constraint.ValueString(), func(v string) bool {
+		return !specs[v].TypedSpec().Value.Deprecated
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Helper function to filter the versions by the constraint and sort them from oldest to newest.
+// Versions which can't be parsed are skipped.
+func matchingVersions(versions []string, constraint string) ([]string, error) {
+	var constraints version.Constraints
+
+	if constraint != "" {
+		var err error
+
+		constraints, err = version.NewConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse version constraint %q: %w", constraint, err)
+		}
+	}
+
+	parsed := make([]*version.Version, 0, len(versions))
+	original := map[*version.Version]string{}
+
+	for _, v := range versions {
+		ver, err := version.NewVersion(v)
+		if err != nil {
+			continue
+		}
+
+		if constraints != nil && !constraints.Check(ver) {
+			continue
+		}
+
+		parsed = append(parsed, ver)
+		original[ver] = v
+	}
+
+	sort.Sort(version.Collection(parsed))
+
+	result := make([]string, 0, len(parsed))
+	for _, ver := range parsed {
+		result = append(result, original[ver])
+	}
+
+	return result, nil
+}
+
+// Helper function to pick the newest of the sorted matching versions which is eligible.
+// Prereleases are skipped unless the constraint explicitly includes a prerelease, e.g. '>= 1.10.0-alpha.0'.
+func latestVersion(matching []string, constraint string, eligible func(v string) bool) types.String {
+	allowPrerelease := false
+
+	if constraint != "" {
+		// the constraint was already validated by matchingVersions
+		constraints, err := version.NewConstraint(constraint)
+		if err == nil {
+			allowPrerelease = slices.ContainsFunc(constraints, (*version.Constraint).Prerelease)
+		}
+	}
+
+	for _, v := range slices.Backward(matching) {
+		ver, err := version.NewVersion(v)
+		if err != nil || (ver.Prerelease() != "" && !allowPrerelease) {
+			continue
+		}
+
+		if eligible == nil || eligible(v) {
+			return types.StringValue(v)
+		}
+	}
+
+	return types.StringNull()
+}
+
+// versionConstraintValidator checks that a string is a valid version constraint.
+type versionConstraintValidator struct{}
+
+func (v versionConstraintValidator) Description(_ context.Context) string {
+	return "value must be a valid version constraint, e.g. '~> 1.9' or '>= 1.8.0, < 1.10.0'"
+}
+
+func (v versionConstraintValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v versionConstraintValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := version.NewConstraint(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Version Constraint",
+			fmt.Sprintf("Failed to parse %q: %s", req.ConfigValue.ValueString(), err),
+		)
+	}
+}
@@ -0,0 +1,63 @@
+package omni
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTalosVersionsDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+data "omni_talos_versions" "v19" {
+  constraint = "~> 1.9.0"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.omni_talos_versions.v19", "latest"),
+					resource.TestCheckResourceAttrSet("data.omni_talos_versions.v19", "versions.0.compatible_kubernetes_versions.0"),
+				),
+			},
+		},
+	})
+}
+
+func TestMatchingVersions(t *testing.T) {
+	versions := []string{"1.10.0", "1.9.5", "1.9.10", "1.8.3", "1.10.0-beta.0", "invalid"}
+
+	matching, err := matchingVersions(versions, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.8.3", "1.9.5", "1.9.10", "1.10.0-beta.0", "1.10.0"}, matching)
+
+	matching, err = matchingVersions(versions, "~> 1.9.0")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.9.5", "1.9.10"}, matching)
+
+	matching, err = matchingVersions(versions, "~> 1.9")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.9.5", "1.9.10", "1.10.0"}, matching)
+
+	_, err = matchingVersions(versions, "not a constraint")
+	require.Error(t, err)
+}
+
+func TestLatestVersion(t *testing.T) {
+	versions := []string{"1.10.0", "1.9.5", "1.9.10", "1.11.0-alpha.1"}
+
+	matching, err := matchingVersions(versions, "")
+	require.NoError(t, err)
+	require.Equal(t, types.StringValue("1.10.0"), latestVersion(matching, "", nil))
+
+	require.Equal(t, types.StringValue("1.9.10"), latestVersion(matching, "", func(v string) bool { return v != "1.10.0" }))
+
+	matching, err = matchingVersions(versions, ">= 1.11.0-alpha.0")
+	require.NoError(t, err)
+	require.Equal(t, types.StringValue("1.11.0-alpha.1"), latestVersion(matching, ">= 1.11.0-alpha.0", nil))
+
+	require.True(t, latestVersion([]string{"1.11.0-alpha.1"}, "", nil).IsNull())
+}
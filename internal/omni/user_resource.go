@@ -0,0 +1,333 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package omni
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cosi_res "github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/auth"
+)
+
+var (
+	_ resource.Resource                = &userResource{}
+	_ resource.ResourceWithImportState = &userResource{}
+)
+
+func NewUserResource() resource.Resource {
+	return &userResource{}
+}
+
+type userResource struct {
+	provider *omniProvider
+}
+
+type userResourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Email      types.String `tfsdk:"email"`
+	Role       types.String `tfsdk:"role"`
+	SAMLLabels types.Map    `tfsdk:"saml_labels"`
+	UserID     types.String `tfsdk:"user_id"`
+}
+
+func (r *userResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_user"
+}
+
+func (r *userResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Manages an Omni user and its email identity.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The email of the user",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"email": schema.StringAttribute{
+				MarkdownDescription: "The email identity of the user",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"role": schema.StringAttribute{
+				MarkdownDescription: "The role of the user ('None', 'Reader', 'Operator' or 'Admin')",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(roleNone, roleReader, roleOperator, roleAdmin),
+				},
+			},
+			"saml_labels": schema.MapAttribute{
+				MarkdownDescription: "SAML labels of the identity, without the `" + auth.SAMLLabelPrefix + "` prefix. " +
+					"They are matched by access policies and SAML label rules",
+				Optional:    true,
+				ElementType: types.StringType,
+			},
+			"user_id": schema.StringAttribute{
+				MarkdownDescription: "The ID of the User resource in Omni",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *userResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	provider, ok := req.ProviderData.(*omniProvider)
+	if !ok {
+		resp.Diagnostics.AddError(
+			errUnexpectedProviderType,
+			fmt.Sprintf("Expected *omniProvider, got: %T", req.ProviderData),
+		)
+		return
+	}
+
+	r.provider = provider
+}
+
+func (r *userResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	st := r.provider.state
+
+	var plan userResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	email := plan.Email.ValueString()
+
+	samlLabels := map[string]string{}
+	resp.Diagnostics.Append(plan.SAMLLabels.ElementsAs(ctx, &samlLabels, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	existing, err := safe.StateGetByID[*auth.Identity](ctx, st, email)
+	if err != nil && !state.IsNotFoundError(err) {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to check identity '%s': %v", email, err))
+		return
+	}
+
+	if existing != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Identity '%s' already exists, import it instead", email))
+		return
+	}
+
+	user := auth.NewUser(resources.DefaultNamespace, uuid.NewString())
+	user.TypedSpec().Value.Role = plan.Role.ValueString()
+
+	identity := auth.NewIdentity(resources.DefaultNamespace, email)
+	identity.TypedSpec().Value.UserId = user.Metadata().ID()
+	identity.Metadata().Labels().Set(auth.LabelIdentityUserID, user.Metadata().ID())
+	setSAMLLabels(identity.Metadata().Labels(), samlLabels)
+
+	if err := st.Create(ctx, user); err != nil {
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to create user for '%s': %v", email, err))
+		return
+	}
+
+	if err := st.Create(ctx, identity); err != nil {
+		// Roll back the user, it would be left in Omni without being tracked in the state
+		if destroyErr := st.Destroy(ctx, user.Metadata()); destroyErr != nil && !state.IsNotFoundError(destroyErr) {
+			resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf(
+				"Failed to create identity '%s': %v, and failed to remove the user '%s' created for it: %v", email, err, user.Metadata().ID(), destroyErr,
+			))
+			return
+		}
+
+		resp.Diagnostics.AddError(errCreationFailed, fmt.Sprintf("Failed to create identity '%s': %v", email, err))
+		return
+	}
+
+	plan.ID = types.StringValue(email)
+	plan.UserID = types.StringValue(user.Metadata().ID())
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *userResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	st := r.provider.state
+
+	var tfState userResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	email := tfState.ID.ValueString()
+
+	identity, err := safe.StateGetByID[*auth.Identity](ctx, st, email)
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read identity '%s': %v", email, err))
+		return
+	}
+
+	userID := identity.TypedSpec().Value.UserId
+
+	user, err := safe.StateGetByID[*auth.User](ctx, st, userID)
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError(errStateError, fmt.Sprintf("Failed to read user '%s': %v", userID, err))
+		return
+	}
+
+	imported := tfState.Role.IsNull()
+
+	tfState.Email = types.StringValue(email)
+	tfState.Role = types.StringValue(user.TypedSpec().Value.Role)
+	tfState.UserID = types.StringValue(userID)
+
+	// SAML labels are only tracked once they are managed, or when the user is imported
+	samlLabels := getSAMLLabels(identity.Metadata().Labels())
+	if !tfState.SAMLLabels.IsNull() || (imported && len(samlLabels) > 0) {
+		labels, diags := types.MapValueFrom(ctx, types.StringType, samlLabels)
+		resp.Diagnostics.Append(diags...)
+		tfState.SAMLLabels = labels
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, tfState)...)
+}
+
+func (r *userResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	st := r.provider.state
+
+	var tfState, plan userResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	samlLabels := map[string]string{}
+	resp.Diagnostics.Append(plan.SAMLLabels.ElementsAs(ctx, &samlLabels, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	userMD := auth.NewUser(resources.DefaultNamespace, tfState.UserID.ValueString()).Metadata()
+
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, userMD, func(user *auth.User) error {
+		user.TypedSpec().Value.Role = plan.Role.ValueString()
+
+		return nil
+	}); err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, fmt.Sprintf("Failed to update role of '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	identityMD := auth.NewIdentity(resources.DefaultNamespace, tfState.ID.ValueString()).Metadata()
+
+	if _, err := safe.StateUpdateWithConflicts(ctx, st, identityMD, func(identity *auth.Identity) error {
+		setSAMLLabels(identity.Metadata().Labels(), samlLabels)
+
+		return nil
+	}); err != nil {
+		resp.Diagnostics.AddError(errUpdateFailed, fmt.Sprintf("Failed to update SAML labels of '%s': %v", tfState.ID.ValueString(), err))
+		return
+	}
+
+	plan.ID = tfState.ID
+	plan.UserID = tfState.UserID
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *userResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	st := r.provider.state
+
+	var tfState userResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &tfState)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	toDelete := []cosi_res.Pointer{
+		auth.NewIdentity(resources.DefaultNamespace, tfState.ID.ValueString()).Metadata(),
+		auth.NewUser(resources.DefaultNamespace, tfState.UserID.ValueString()).Metadata(),
+	}
+
+	for _, md := range toDelete {
+		if err := teardownAndDestroy(ctx, st, md); err != nil {
+			resp.Diagnostics.AddError(errDeleteFailed, err.Error())
+			return
+		}
+	}
+}
+
+func (r *userResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// Helper functions
+func setSAMLLabels(labels *cosi_res.Labels, samlLabels map[string]string) {
+	for _, key := range labels.Keys() {
+		if strings.HasPrefix(key, auth.SAMLLabelPrefix) {
+			labels.Delete(key)
+		}
+	}
+
+	for key, value := range samlLabels {
+		labels.Set(auth.SAMLLabelPrefix+key, value)
+	}
+}
+
+func getSAMLLabels(labels *cosi_res.Labels) map[string]string {
+	samlLabels := map[string]string{}
+
+	for key, value := range labels.Raw() {
+		if strings.HasPrefix(key, auth.SAMLLabelPrefix) {
+			samlLabels[strings.TrimPrefix(key, auth.SAMLLabelPrefix)] = value
+		}
+	}
+
+	return samlLabels
+}
+
+// teardownAndDestroy tears the resource down, waits for its finalizers to be removed and destroys it.
+func teardownAndDestroy(ctx context.Context, st state.State, md cosi_res.Pointer) error {
+	if _, err := st.Teardown(ctx, md); err != nil {
+		if state.IsNotFoundError(err) {
+			return nil // Resource already deleted
+		}
+		return fmt.Errorf("failed to tear down resource '%s' of type '%s': %v", md.ID(), md.Type(), err)
+	}
+
+	if _, err := st.WatchFor(ctx, md, state.WithFinalizerEmpty()); err != nil {
+		return fmt.Errorf("failed to wait for resource '%s' of type '%s' to be released: %v", md.ID(), md.Type(), err)
+	}
+
+	if err := st.Destroy(ctx, md); err != nil && !state.IsNotFoundError(err) {
+		return fmt.Errorf("failed to delete resource '%s' of type '%s': %v", md.ID(), md.Type(), err)
+	}
+
+	return nil
+}
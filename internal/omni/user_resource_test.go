@@ -0,0 +1,110 @@
+package omni
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cosi_res "github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// Create new user
+				Config: providerConfig + `
+resource "omni_user" "test" {
+  email = "terraform-test@example.com"
+  role  = "Reader"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_user.test", "id", "terraform-test@example.com"),
+					resource.TestCheckResourceAttr("omni_user.test", "role", "Reader"),
+					resource.TestCheckResourceAttrSet("omni_user.test", "user_id"),
+				),
+			},
+			{
+				// Update role and SAML labels
+				Config: providerConfig + `
+resource "omni_user" "test" {
+  email = "terraform-test@example.com"
+  role  = "Operator"
+  saml_labels = {
+    groups = "platform"
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("omni_user.test", "role", "Operator"),
+					resource.TestCheckResourceAttr("omni_user.test", "saml_labels.groups", "platform"),
+				),
+			},
+			{
+				// Import by email
+				ResourceName:      "omni_user.test",
+				ImportState:       true,
+				ImportStateId:     "terraform-test@example.com",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestSAMLLabels(t *testing.T) {
+	md := cosi_res.NewMetadata("default", "Identities.omni.sidero.dev", "user@example.com", cosi_res.VersionUndefined)
+	md.Labels().Set("user-id", "1234")
+	md.Labels().Set("saml.omni.sidero.dev/role", "old")
+
+	setSAMLLabels(md.Labels(), map[string]string{"groups": "platform"})
+
+	require.Equal(t, map[string]string{"groups": "platform"}, getSAMLLabels(md.Labels()))
+
+	userID, ok := md.Labels().Get("user-id")
+	require.True(t, ok, "non SAML labels should be kept")
+	require.Equal(t, "1234", userID)
+}
+
+// identityFailingState fails the creation of identities.
+type identityFailingState struct {
+	state.CoreState
+}
+
+func (s identityFailingState) Create(ctx context.Context, r cosi_res.Resource, opts ...state.CreateOption) error {
+	if r.Metadata().Type() == auth.IdentityType {
+		return errors.New("identity rejected")
+	}
+
+	return s.CoreState.Create(ctx, r, opts...)
+}
+
+func TestUserCreateRollsBackUserWhenIdentityFails(t *testing.T) {
+	inner := newTestState()
+	r := &userResource{provider: &omniProvider{state: state.WrapCore(identityFailingState{CoreState: inner})}}
+
+	resp := createWithPlan(t, r, userResourceModel{
+		ID:         types.StringUnknown(),
+		Email:      types.StringValue("user@example.com"),
+		Role:       types.StringValue("Reader"),
+		SAMLLabels: types.MapNull(types.StringType),
+		UserID:     types.StringUnknown(),
+	})
+	require.True(t, resp.Diagnostics.HasError())
+	require.Contains(t, resp.Diagnostics.Errors()[0].Detail(), "identity rejected")
+
+	users, err := safe.StateListAll[*auth.User](t.Context(), inner)
+	require.NoError(t, err)
+	require.Zero(t, users.Len())
+
+	_, err = inner.Get(t.Context(), auth.NewIdentity(resources.DefaultNamespace, "user@example.com").Metadata())
+	require.True(t, state.IsNotFoundError(err))
+}
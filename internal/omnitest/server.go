@@ -0,0 +1,210 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package omnitest provides a local Omni stand-in for the provider tests.
+//
+// The server serves the COSI state API backed by an in-memory state and a fake management API
+// on a local port, so acceptance tests can run without a live Omni instance.
+package omnitest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cosi-project/runtime/api/v1alpha1"
+	"github.com/cosi-project/runtime/pkg/resource"
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/cosi-project/runtime/pkg/state/impl/inmem"
+	"github.com/cosi-project/runtime/pkg/state/impl/namespaced"
+	"github.com/cosi-project/runtime/pkg/state/protobuf/server"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/siderolabs/omni/client/pkg/client"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // the Omni client compresses the requests
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"gopkg.in/yaml.v3"
+
+	_ "github.com/siderolabs/omni/client/pkg/omni/resources/auth" // register the Omni resource types
+	_ "github.com/siderolabs/omni/client/pkg/omni/resources/infra"
+	_ "github.com/siderolabs/omni/client/pkg/omni/resources/k8s"
+	_ "github.com/siderolabs/omni/client/pkg/omni/resources/siderolink"
+	_ "github.com/siderolabs/omni/client/pkg/omni/resources/system"
+	_ "github.com/siderolabs/omni/client/pkg/omni/resources/virtual"
+)
+
+const pxeBaseURL = "https://pxe.factory.talos.dev"
+
+// Server is a local Omni stand-in.
+type Server struct {
+	state    state.State
+	listener net.Listener
+	client   *client.Client
+}
+
+// NewServer starts a server with an empty state, it is stopped when the test finishes.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	srv := &Server{
+		state:    state.WrapCore(namespaced.NewState(inmem.Build)),
+		listener: listener,
+	}
+
+	grpcServer := grpc.NewServer()
+	v1alpha1.RegisterStateServer(grpcServer, server.NewState(srv.state))
+	management.RegisterManagementServiceServer(grpcServer, &managementServer{state: srv.state})
+
+	go grpcServer.Serve(listener) //nolint:errcheck
+
+	srv.client, err = client.New(srv.Endpoint())
+	if err != nil {
+		grpcServer.Stop()
+		t.Fatalf("failed to create Omni client: %v", err)
+	}
+
+	t.Cleanup(func() {
+		srv.client.Close() //nolint:errcheck
+		grpcServer.Stop()
+	})
+
+	return srv
+}
+
+// Endpoint returns the endpoint the server listens on.
+func (srv *Server) Endpoint() string {
+	return "http://" + srv.listener.Addr().String()
+}
+
+// Client returns an Omni client connected to the server.
+func (srv *Server) Client() *client.Client {
+	return srv.client
+}
+
+// State returns the in-memory state of the server, e.g. to create the resources Omni manages itself.
+func (srv *Server) State() state.State { //nolint:ireturn
+	return srv.state
+}
+
+// Seed creates the resources in the server state, e.g. the machines or installation media which Omni manages itself.
+func (srv *Server) Seed(t *testing.T, res ...resource.Resource) {
+	t.Helper()
+
+	for _, r := range res {
+		if err := srv.state.Create(t.Context(), r); err != nil {
+			t.Fatalf("failed to create resource %s: %v", r.Metadata(), err)
+		}
+	}
+}
+
+// SchematicID returns the schematic ID the server generates for the request.
+//
+// The ID only depends on the schematic customization like the image factory,
+// but it differs from the ID the image factory would return.
+func SchematicID(req *management.CreateSchematicRequest) string {
+	customization := &management.CreateSchematicRequest{
+		Extensions:               slices.Sorted(slices.Values(req.Extensions)),
+		ExtraKernelArgs:          req.ExtraKernelArgs,
+		MetaValues:               req.MetaValues,
+		SiderolinkGrpcTunnelMode: req.SiderolinkGrpcTunnelMode,
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(customization)
+	if err != nil {
+		panic(err)
+	}
+
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:])
+}
+
+// managementServer implements the parts of the management API used by the provider.
+type managementServer struct {
+	management.UnimplementedManagementServiceServer
+
+	state state.State
+}
+
+func (s *managementServer) CreateSchematic(ctx context.Context, req *management.CreateSchematicRequest) (*management.CreateSchematicResponse, error) {
+	schematicID := SchematicID(req)
+
+	resp := &management.CreateSchematicResponse{
+		SchematicId:       schematicID,
+		GrpcTunnelEnabled: req.SiderolinkGrpcTunnelMode == management.CreateSchematicRequest_ENABLED,
+	}
+
+	if req.MediaId == "" {
+		return resp, nil
+	}
+
+	media, err := safe.StateGetByID[*omni.InstallationMedia](ctx, s.state, req.MediaId)
+	if err != nil {
+		if state.IsNotFoundError(err) {
+			return nil, status.Errorf(codes.NotFound, "installation media %q not found", req.MediaId)
+		}
+
+		return nil, err
+	}
+
+	talosVersion := "v" + strings.TrimPrefix(req.TalosVersion, "v")
+	spec := media.TypedSpec().Value
+
+	resp.PxeUrl = fmt.Sprintf("%s/pxe/%s/%s/%s-%s", pxeBaseURL, schematicID, talosVersion, spec.Profile, spec.Architecture)
+
+	return resp, nil
+}
+
+func (s *managementServer) Kubeconfig(ctx context.Context, _ *management.KubeconfigRequest) (*management.KubeconfigResponse, error) {
+	clusterName := "default"
+	if values := metadata.ValueFromIncomingContext(ctx, "context"); len(values) > 0 {
+		clusterName = values[0]
+	}
+
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+  - name: %[1]s
+    cluster:
+      server: https://%[1]s.kubernetes.omni.local
+contexts:
+  - name: %[1]s
+    context:
+      cluster: %[1]s
+      user: %[1]s
+current-context: %[1]s
+users:
+  - name: %[1]s
+    user:
+      token: omnitest
+`, clusterName)
+
+	return &management.KubeconfigResponse{Kubeconfig: []byte(kubeconfig)}, nil
+}
+
+func (s *managementServer) ValidateConfig(_ context.Context, req *management.ValidateConfigRequest) (*emptypb.Empty, error) {
+	var config map[string]any
+
+	if err := yaml.Unmarshal([]byte(req.Config), &config); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to validate config: %v", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
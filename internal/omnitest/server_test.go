@@ -0,0 +1,96 @@
+package omnitest
+
+import (
+	"testing"
+
+	"github.com/cosi-project/runtime/pkg/safe"
+	"github.com/cosi-project/runtime/pkg/state"
+	"github.com/siderolabs/omni/client/api/omni/management"
+	"github.com/siderolabs/omni/client/api/omni/specs"
+	"github.com/siderolabs/omni/client/pkg/omni/resources"
+	"github.com/siderolabs/omni/client/pkg/omni/resources/omni"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerState(t *testing.T) {
+	srv := NewServer(t)
+	st := srv.Client().Omni().State()
+
+	machineClass := omni.NewMachineClass(resources.DefaultNamespace, "workers")
+	machineClass.TypedSpec().Value.MatchLabels = []string{"role = worker"}
+
+	require.NoError(t, st.Create(t.Context(), machineClass))
+
+	got, err := safe.StateGetByID[*omni.MachineClass](t.Context(), st, "workers")
+	require.NoError(t, err)
+	require.Equal(t, []string{"role = worker"}, got.TypedSpec().Value.MatchLabels)
+
+	_, err = safe.StateUpdateWithConflicts(t.Context(), st, machineClass.Metadata(), func(res *omni.MachineClass) error {
+		res.TypedSpec().Value.MatchLabels = []string{"role = gpu"}
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Resources seeded directly in the server state are visible through the API
+	machine := omni.NewMachineStatus(resources.DefaultNamespace, "machine-1")
+	machine.Metadata().Labels().Set(omni.LabelCluster, "talos-default")
+	srv.Seed(t, machine)
+
+	machines, err := safe.StateListAll[*omni.MachineStatus](t.Context(), st)
+	require.NoError(t, err)
+	require.Equal(t, 1, machines.Len())
+
+	require.NoError(t, st.Destroy(t.Context(), machineClass.Metadata()))
+
+	_, err = st.Get(t.Context(), machineClass.Metadata())
+	require.True(t, state.IsNotFoundError(err))
+}
+
+func TestServerManagement(t *testing.T) {
+	srv := NewServer(t)
+
+	media := omni.NewInstallationMedia(resources.EphemeralNamespace, "iso-amd64.iso")
+	media.TypedSpec().Value = &specs.InstallationMediaSpec{Architecture: "amd64", Profile: "metal", Extension: "iso"}
+	srv.Seed(t, media)
+
+	req := &management.CreateSchematicRequest{
+		Extensions:   []string{"siderolabs/qemu-guest-agent", "siderolabs/iscsi-tools"},
+		MediaId:      "iso-amd64.iso",
+		TalosVersion: "1.9.5",
+	}
+
+	schematic, err := srv.Client().Management().CreateSchematic(t.Context(), req)
+	require.NoError(t, err)
+	require.Equal(t, SchematicID(req), schematic.SchematicId)
+	require.Equal(t, "https://pxe.factory.talos.dev/pxe/"+schematic.SchematicId+"/v1.9.5/metal-amd64", schematic.PxeUrl)
+
+	// The ID does not depend on the order of the extensions or the media
+	require.Equal(t, schematic.SchematicId, SchematicID(&management.CreateSchematicRequest{
+		Extensions: []string{"siderolabs/iscsi-tools", "siderolabs/qemu-guest-agent"},
+	}))
+
+	_, err = srv.Client().Management().CreateSchematic(t.Context(), &management.CreateSchematicRequest{MediaId: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	kubeconfig, err := srv.Client().Management().WithCluster("talos-default").Kubeconfig(t.Context())
+	require.NoError(t, err)
+	require.Contains(t, string(kubeconfig), "server: https://talos-default.kubernetes.omni.local")
+
+	conn, err := grpc.NewClient(srv.listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() }) //nolint:errcheck
+
+	managementClient := management.NewManagementServiceClient(conn)
+
+	_, err = managementClient.ValidateConfig(t.Context(), &management.ValidateConfigRequest{Config: "machine:\n  network:\n    hostname: worker-1\n"})
+	require.NoError(t, err)
+
+	_, err = managementClient.ValidateConfig(t.Context(), &management.ValidateConfigRequest{Config: "machine: [unclosed"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}